@@ -18,6 +18,7 @@ import (
 	"9fans.net/go/plumb"
 	"github.com/rjkroege/edwood/draw"
 	"github.com/rjkroege/edwood/dumpfile"
+	"github.com/rjkroege/edwood/internal/theme"
 )
 
 var (
@@ -32,6 +33,8 @@ var (
 	winsize           = flag.String("W", "1024x768", "Window size and position as WidthxHeight[@X,Y]")
 	ncol              = flag.Int("c", 2, "Number of columns at startup")
 	loadfile          = flag.String("l", "", "Load state from file generated with Dump command")
+	themeflag         = flag.String("theme", "", "Theme file (default $HOME/lib/edwood/theme)")
+	undoflag          = flag.Bool("u", false, "Keep undo history across sessions in $HOME/lib/edwood/undo")
 )
 
 func predrawInit() *dumpfile.Content {
@@ -67,6 +70,22 @@ func predrawInit() *dumpfile.Content {
 		}
 	}
 
+	if hooks, err := loadFileHooks(fileHookPath()); err == nil {
+		g.filehooks = hooks
+	} else {
+		log.Printf("file hooks: %v", err)
+	}
+
+	themefile := *themeflag
+	if themefile == "" {
+		themefile = theme.Path(g.home)
+	}
+	if th, err := theme.Load(themefile); err == nil {
+		g.theme = th
+	} else {
+		log.Printf("theme: %v", err)
+	}
+
 	global.tagfont = *varfontflag
 	os.Setenv("font", *varfontflag)
 
@@ -136,6 +155,7 @@ func mainWithDisplay(g *globals, dump *dumpfile.Content, display draw.Display) {
 	go waitthread(g, ctx)
 	go newwindowthread(g)
 	go xfidallocthread(g, ctx, display)
+	go watchthread(g)
 
 	signal.Ignore(ignoreSignals...)
 	signal.Notify(g.csignal, hangupSignals...)
@@ -172,6 +192,7 @@ func readfile(c *Column, filename string) {
 	w.SetName(abspath)
 	w.body.Load(0, filename, true)
 	w.body.file.Clean()
+	restoreUndoJournal(&w.body)
 	w.Resize(w.r, false, true)
 	w.body.ScrDraw()
 	w.tag.SetSelect(w.tag.file.Nr(), w.tag.file.Nr())
@@ -534,14 +555,24 @@ func MovedMouse(g *globals, m draw.Mouse) {
 			}
 			if t.w != nil && t == &t.w.body {
 				g.activewin = t.w
+				if t.q0 == t.q1 {
+					t.w.showDiagnostics(t.q0, t.q1, false)
+				}
 			}
 		case m.Buttons&2 != 0:
 			if q0, q1, argt, ok := t.Select2(); ok {
 				execute(t, q0, q1, false, argt)
 			}
 		case m.Buttons&4 != 0:
-			if q0, q1, ok := t.Select3(); ok {
+			q0, q1, ok, add := t.Select3()
+			switch {
+			case ok:
 				look3(t, q0, q1, false)
+			case add && t.w != nil && t == &t.w.body:
+				t.addSelection(q0, q1)
+				g.argtext = t
+				g.seltext = t
+				g.activewin = t.w
 			}
 		}
 		return
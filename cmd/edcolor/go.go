@@ -56,36 +56,36 @@ func tokenizeGo(src string) []region {
 			byteEnd += len(tok.String())
 		}
 
-		color, bold := goTokenStyle(tok, lit)
-		if color == "" {
+		class := goTokenClass(tok, lit)
+		if class == "" {
 			continue
 		}
 
 		runeStart := b2r[byteOff]
 		runeEnd := b2r[byteEnd]
 		if runeEnd > runeStart {
-			regions = append(regions, region{runeStart, runeEnd, color, bold})
+			regions = append(regions, region{runeStart, runeEnd, class})
 		}
 	}
 
 	return regions
 }
 
-// goTokenStyle returns the color and bold flag for a Go token.
-// An empty color means use default (skip coloring this token).
-func goTokenStyle(tok token.Token, lit string) (color string, bold bool) {
+// goTokenClass returns the class of a Go token. An empty class means
+// use default (skip coloring this token).
+func goTokenClass(tok token.Token, lit string) string {
 	switch {
 	case tok.IsKeyword():
-		return colorKeyword, true
+		return classKeyword
 	case tok == token.COMMENT:
-		return colorComment, false
+		return classComment
 	case tok == token.STRING, tok == token.CHAR:
-		return colorString, false
+		return classString
 	case tok == token.INT, tok == token.FLOAT, tok == token.IMAG:
-		return colorNumber, false
+		return classNumber
 	case tok == token.IDENT && goBuiltins[lit]:
-		return colorBuiltin, false
+		return classBuiltin
 	default:
-		return "", false
+		return ""
 	}
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rjkroege/edwood/internal/theme"
 )
 
 func TestTokenizeGo(t *testing.T) {
@@ -19,36 +22,35 @@ func greet(name string) {
 
 	type want struct {
 		text  string
-		color string
-		bold  bool
+		class string
 	}
 	// Collect actual region texts.
 	runes := []rune(src)
 	var got []want
 	for _, r := range regions {
-		got = append(got, want{string(runes[r.runeStart:r.runeEnd]), r.color, r.bold})
+		got = append(got, want{string(runes[r.runeStart:r.runeEnd]), r.class})
 	}
 
 	wantRegions := []want{
-		{"package", colorKeyword, true},
-		{`"fmt"`, colorString, false},
-		{"// greet prints a greeting.", colorComment, false},
-		{"func", colorKeyword, true},
-		{"string", colorBuiltin, false},
-		{"42", colorNumber, false},
-		{`"Hello, "`, colorString, false},
+		{"package", classKeyword},
+		{`"fmt"`, classString},
+		{"// greet prints a greeting.", classComment},
+		{"func", classKeyword},
+		{"string", classBuiltin},
+		{"42", classNumber},
+		{`"Hello, "`, classString},
 	}
 
 	for _, w := range wantRegions {
 		found := false
 		for _, g := range got {
-			if g.text == w.text && g.color == w.color && g.bold == w.bold {
+			if g == w {
 				found = true
 				break
 			}
 		}
 		if !found {
-			t.Errorf("missing region: %q color=%s bold=%v", w.text, w.color, w.bold)
+			t.Errorf("missing region: %q class=%s", w.text, w.class)
 		}
 	}
 }
@@ -75,6 +77,45 @@ func TestColorizeGo(t *testing.T) {
 	}
 }
 
+func TestSpansInTheme(t *testing.T) {
+	th := defaultTheme.Overlay(theme.Theme{
+		classKeyword:   {Fg: "#ffffff", Bg: "#000000", Italic: true},
+		"comment.doc":  {Fg: "#111111"},
+		classString:    {},
+		classHighlight: {Bg: "#222222"},
+	})
+	regions := []region{
+		{0, 2, classKeyword},
+		{3, 5, "comment.doc"},
+		{5, 7, "comment.line"},
+		{7, 9, classString},
+		{9, 10, "unknown"},
+	}
+	want := []span{
+		{0, 2, "#ffffff", "#000000", false, true},
+		{2, 1, "-", "", false, false},
+		{3, 2, "#111111", "", false, false},
+		{5, 2, "#808080", "", false, false},
+		{7, 2, "-", "", false, false},
+		{9, 1, "-", "", false, false},
+		{10, 2, "-", "", false, false},
+	}
+	spans := spansIn(regions, th, 0, 12)
+	if diff := cmp.Diff(want, spans, cmp.AllowUnexported(span{})); diff != "" {
+		t.Errorf("spansIn mismatch (-want +got):\n%s", diff)
+	}
+
+	hl, _ := th.Lookup(classHighlight)
+	got := applyHighlights(spans[:1], [][2]int{{1, 2}}, hl.Bg)
+	want = []span{
+		{0, 1, "#ffffff", "#000000", false, true},
+		{1, 1, "#ffffff", "#222222", false, true},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(span{})); diff != "" {
+		t.Errorf("applyHighlights mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestColorizeGoViewport(t *testing.T) {
 	// Source with multiple lines to test viewport filtering.
 	src := "package main\n\nimport \"fmt\"\n\nfunc f() { fmt.Println(42) }\n"
@@ -132,17 +173,16 @@ func TestColorizeGoViewport(t *testing.T) {
 	}
 }
 
-func TestGoTokenStyle(t *testing.T) {
+func TestGoTokenClass(t *testing.T) {
 	tests := []struct {
 		name  string
 		src   string
-		color string
-		bold  bool
+		class string
 	}{
-		{"keyword", "func", colorKeyword, true},
-		{"string", `"hello"`, colorString, false},
-		{"number", "42", colorNumber, false},
-		{"comment", "// comment", colorComment, false},
+		{"keyword", "func", classKeyword},
+		{"string", `"hello"`, classString},
+		{"number", "42", classNumber},
+		{"comment", "// comment", classComment},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -151,11 +191,8 @@ func TestGoTokenStyle(t *testing.T) {
 				t.Fatal("no regions returned")
 			}
 			r := regions[0]
-			if r.color != tt.color {
-				t.Errorf("color = %q, want %q", r.color, tt.color)
-			}
-			if r.bold != tt.bold {
-				t.Errorf("bold = %v, want %v", r.bold, tt.bold)
+			if r.class != tt.class {
+				t.Errorf("class = %q, want %q", r.class, tt.class)
 			}
 		})
 	}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rjkroege/edwood/regexp"
+)
+
+// Declarative grammars.
+//
+// Languages without a hand-written lexer are colored by grammars read
+// from files, in the style of TextMate grammars: regular-expression
+// rules that assign scopes to text. A scope is the class of its
+// regions, which the theme maps to colors. A grammar file holds one
+// directive per line:
+//
+//	# C
+//	files   *.c *.h
+//	begin   comment         /\*
+//	end     \*/
+//	match   comment         //.*
+//	match   string          "(\\.|[^"\\])*"
+//	match   keyword         \b(if|else|for|while|return)\b
+//
+// files lists glob patterns matched, case-insensitively, against the
+// base name of the window's file. match assigns its scope to the text
+// matched by the regular expression, which is the rest of the line.
+// begin starts a region that runs to the next match of the end
+// expression on the following end line, or to the end of the body if
+// there is none; a skip line after begin names text, such as an
+// escaped quote, that is stepped over while looking for the end. The
+// scopes used by the built-in grammars are styled in defaultTheme.
+//
+// Text is scanned line by line. At each point the rule whose match
+// starts first wins, the earlier rule on a tie. Match rules never
+// cross a line break; only begin/end regions do, which keeps the
+// grammars compatible with incremental re-lexing. The expressions use
+// edwood's regexp package, in which ^ and $ match at line boundaries.
+//
+// Built-in grammars are compiled into edcolor. Grammar files in
+// $HOME/lib/edwood/grammars add to them, replacing a built-in grammar
+// with the same file name.
+
+//go:embed grammars
+var builtinGrammars embed.FS
+
+// grammar is a set of scoped rules for one language.
+type grammar struct {
+	name  string
+	files []string // base-name glob patterns
+	rules []rule
+}
+
+// rule is a match rule, or a begin/end region if end is set.
+type rule struct {
+	scope string
+	match *regexp.Regexp // the whole token, or the start of a region
+	end   *regexp.Regexp
+	skip  *regexp.Regexp // stepped over while looking for end
+}
+
+// grammarDir returns the directory of the user's grammar files.
+func grammarDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "lib", "edwood", "grammars")
+}
+
+// parseGrammar reads a grammar from r. name is used to label errors.
+func parseGrammar(r io.Reader, name string) (*grammar, error) {
+	g := &grammar{name: name}
+	var open *rule // the last begin rule, while end and skip may follow
+	sc := bufio.NewScanner(r)
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		verb, rest := cutField(line)
+		errorf := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", name, lineno, fmt.Sprintf(format, args...))
+		}
+		if open != nil && open.end == nil && verb != "end" && verb != "skip" {
+			return nil, errorf("begin for scope %s without end", open.scope)
+		}
+		switch verb {
+		case "files":
+			pats := strings.Fields(rest)
+			if len(pats) == 0 {
+				return nil, errorf("files needs a pattern")
+			}
+			for _, p := range pats {
+				if _, err := path.Match(p, ""); err != nil {
+					return nil, errorf("bad pattern %q: %v", p, err)
+				}
+			}
+			g.files = append(g.files, pats...)
+			open = nil
+		case "match", "begin":
+			scope, expr := cutField(rest)
+			if expr == "" {
+				return nil, errorf("%s needs a scope and an expression", verb)
+			}
+			re, err := regexp.CompileAcme(expr)
+			if err != nil {
+				return nil, errorf("%v", err)
+			}
+			g.rules = append(g.rules, rule{scope: scope, match: re})
+			open = nil
+			if verb == "begin" {
+				open = &g.rules[len(g.rules)-1]
+			}
+		case "end", "skip":
+			if open == nil {
+				return nil, errorf("%s without begin", verb)
+			}
+			if rest == "" {
+				return nil, errorf("%s needs an expression", verb)
+			}
+			re, err := regexp.CompileAcme(rest)
+			if err != nil {
+				return nil, errorf("%v", err)
+			}
+			if verb == "end" {
+				open.end = re
+			} else {
+				open.skip = re
+			}
+		default:
+			return nil, errorf("unknown directive %q", verb)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if open != nil && open.end == nil {
+		return nil, fmt.Errorf("%s: begin for scope %s without end", name, open.scope)
+	}
+	return g, nil
+}
+
+// cutField splits s into its first whitespace-separated field and the
+// rest, with surrounding space removed.
+func cutField(s string) (field, rest string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// loadGrammars returns the built-in grammars and those in dir, in
+// file name order. A grammar that fails to parse is reported with
+// warn and left out; a missing dir is not an error.
+func loadGrammars(dir string) []*grammar {
+	files := make(map[string]func() (io.ReadCloser, error))
+	builtin, _ := fs.Sub(builtinGrammars, "grammars")
+	entries, _ := fs.ReadDir(builtin, ".")
+	for _, e := range entries {
+		name := e.Name()
+		files[name] = func() (io.ReadCloser, error) { return builtin.Open(name) }
+	}
+	entries, _ = os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		files[e.Name()] = func() (io.ReadCloser, error) { return os.Open(p) }
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var grammars []*grammar
+	for _, name := range names {
+		f, err := files[name]()
+		if err != nil {
+			warn(err)
+			continue
+		}
+		g, err := parseGrammar(f, name)
+		f.Close()
+		if err != nil {
+			warn(err)
+			continue
+		}
+		grammars = append(grammars, g)
+	}
+	return grammars
+}
+
+// grammarFor returns the first grammar whose files patterns match the
+// base name of file, or nil.
+func grammarFor(grammars []*grammar, file string) *grammar {
+	base := strings.ToLower(filepath.Base(file))
+	for _, g := range grammars {
+		for _, p := range g.files {
+			if ok, _ := path.Match(strings.ToLower(p), base); ok {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// tokenize lexes src with g.
+func (g *grammar) tokenize(src string) []region {
+	return g.lex([]rune(src))
+}
+
+// ruleMatch is the cached first match of a rule on the current line,
+// found by searching from the position from.
+type ruleMatch struct {
+	from       int
+	start, end int // start < 0: none from from to the end of the line
+}
+
+func (g *grammar) lex(src []rune) []region {
+	var regions []region
+	cache := make([]ruleMatch, len(g.rules))
+	line := -1 // end of the line the cache is for
+	for p := 0; p < len(src); {
+		le := lineEnd(src, p)
+		if le != line {
+			line = le
+			for k := range cache {
+				cache[k] = ruleMatch{from: len(src) + 1}
+			}
+		}
+
+		// Find the rule whose match starts first; a cached match is
+		// still the first one if it is not behind p.
+		best := -1
+		for k := range g.rules {
+			m := &cache[k]
+			if m.from > p || (m.start >= 0 && m.start < p) {
+				m.from = p
+				m.start, m.end = find(g.rules[k].match, src, p, le)
+			}
+			if m.start >= 0 && (best < 0 || m.start < cache[best].start) {
+				best = k
+			}
+		}
+		if best < 0 {
+			p = le + 1
+			continue
+		}
+
+		r := &g.rules[best]
+		start, end := cache[best].start, cache[best].end
+		if r.end != nil {
+			end = r.close(src, end)
+		}
+		regions = append(regions, region{start, end, r.scope})
+		p = end
+	}
+	return regions
+}
+
+// close returns the end of a region of r whose begin match ends at p:
+// the end of the first match of r.end, or len(src).
+func (r *rule) close(src []rune, p int) int {
+	for {
+		le := lineEnd(src, p)
+		for p <= le {
+			s, e := find(r.end, src, p, le)
+			if r.skip != nil {
+				ks, ke := find(r.skip, src, p, le)
+				if ks >= 0 && (s < 0 || ks <= s) {
+					p = ke
+					continue
+				}
+			}
+			if s >= 0 {
+				return e
+			}
+			break
+		}
+		if le >= len(src) {
+			return len(src)
+		}
+		p = le + 1
+	}
+}
+
+// find returns the first non-empty match of re in src[from:to], or
+// -1, -1.
+func find(re *regexp.Regexp, src []rune, from, to int) (start, end int) {
+	for from <= to {
+		m := re.FindForward(src, from, to, 1)
+		if m == nil {
+			break
+		}
+		if m[0][1] > m[0][0] {
+			return m[0][0], m[0][1]
+		}
+		from = m[0][0] + 1
+	}
+	return -1, -1
+}
+
+// lineEnd returns the offset of the newline ending the line holding
+// p, or len(src).
+func lineEnd(src []rune, p int) int {
+	for p < len(src) && src[p] != '\n' {
+		p++
+	}
+	return p
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// grammarTokens lexes src with g and returns the text of each region
+// with its class.
+func grammarTokens(g *grammar, src string) [][2]string {
+	runes := []rune(src)
+	var got [][2]string
+	for _, r := range g.lex(runes) {
+		got = append(got, [2]string{string(runes[r.runeStart:r.runeEnd]), r.class})
+	}
+	return got
+}
+
+func mustParseGrammar(t *testing.T, src string) *grammar {
+	t.Helper()
+	g, err := parseGrammar(strings.NewReader(src), "test")
+	if err != nil {
+		t.Fatalf("parseGrammar failed: %v", err)
+	}
+	return g
+}
+
+func TestGrammarLex(t *testing.T) {
+	g := mustParseGrammar(t, `
+# A small C-like language.
+files   *.x
+begin   comment   /\*
+end     \*/
+begin   string    "
+skip    \\.
+end     "
+match   comment   //.*
+match   keyword   \b(if|return)\b
+match   number    \b[0-9]+\b
+match   plain     [a-z]+[0-9]+
+`)
+	src := "if x1 /* a\nb */ return \"q\\\"\n\" // 2\n3 é \"open\nend"
+	want := [][2]string{
+		{"if", "keyword"},
+		{"x1", "plain"},
+		{"/* a\nb */", "comment"},
+		{"return", "keyword"},
+		{"\"q\\\"\n\"", "string"},
+		{"// 2", "comment"},
+		{"3", "number"},
+		{"\"open\nend", "string"},
+	}
+	if diff := cmp.Diff(want, grammarTokens(g, src)); diff != "" {
+		t.Errorf("lex mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGrammarFirstMatchWins(t *testing.T) {
+	g := mustParseGrammar(t, `
+match   number    [0-9]+
+match   keyword   [a-w0-9]+
+match   string    x*
+`)
+	// The earliest match wins over rule order; on a tie the earlier rule
+	// wins. The empty matches of x* are ignored.
+	want := [][2]string{
+		{"ab12", "keyword"},
+		{"34", "number"},
+		{"xx", "string"},
+	}
+	if diff := cmp.Diff(want, grammarTokens(g, "ab12 34 xx")); diff != "" {
+		t.Errorf("lex mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseGrammarErrors(t *testing.T) {
+	for _, src := range []string{
+		"bogus x",
+		"files",
+		"files [",
+		"match keyword",
+		"match keyword (",
+		"end x",
+		"match comment x\nskip y",
+		"begin comment x\nmatch keyword y",
+		"begin comment x",
+		"begin comment x\nend",
+	} {
+		if _, err := parseGrammar(strings.NewReader(src), "test"); err == nil {
+			t.Errorf("parseGrammar(%q) succeeded; want error", src)
+		}
+	}
+}
+
+func TestBuiltinGrammars(t *testing.T) {
+	grammars := loadGrammars(filepath.Join(t.TempDir(), "none"))
+	for _, tc := range []struct {
+		file, grammar string
+	}{
+		{"/src/main.c", "c"},
+		{"x.H", "c"},
+		{"build.sh", "sh"},
+		{"ci.yml", "yaml"},
+		{"app.tsx", "typescript"},
+		{"/src/Makefile", "make"},
+		{"mkfile", "make"},
+	} {
+		g := grammarFor(grammars, tc.file)
+		if g == nil || g.name != tc.grammar {
+			t.Errorf("grammarFor(%q) = %v; want grammar %s", tc.file, g, tc.grammar)
+		}
+	}
+	if g := grammarFor(grammars, "main.go"); g != nil {
+		t.Errorf("grammarFor(main.go) = grammar %s; want none", g.name)
+	}
+
+	c := grammarFor(grammars, "a.c")
+	want := [][2]string{
+		{"#include", "keyword.preprocessor"},
+		{`"a.h"`, "string"},
+		{"/* x */", "comment"},
+		{"int", "type"},
+		{"return", "keyword"},
+		{"0x1f", "number"},
+	}
+	if diff := cmp.Diff(want, grammarTokens(c, "#include \"a.h\"\n/* x */ int f() { return 0x1f; }\n")); diff != "" {
+		t.Errorf("C lex mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUserGrammarReplacesBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "c"), []byte("files *.c\nmatch keyword int\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lua"), []byte("files *.lua\nmatch comment --.*\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	grammars := loadGrammars(dir)
+	if g := grammarFor(grammars, "a.h"); g != nil {
+		t.Errorf("a.h matched grammar %s after the C grammar was replaced", g.name)
+	}
+	if g := grammarFor(grammars, "a.lua"); g == nil || g.name != "lua" {
+		t.Errorf("grammarFor(a.lua) = %v; want the user's lua grammar", g)
+	}
+}
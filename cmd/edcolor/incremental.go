@@ -0,0 +1,173 @@
+package main
+
+import (
+	"slices"
+	"sort"
+)
+
+// Incremental re-lexing.
+//
+// The lexers carry no state across a line break except when a token
+// (a block comment, raw string or multi-line math) spans it. So the
+// only lexer state at the start of a line is whether it begins inside
+// a token, and lexing from any line start that does not gives the
+// same tokens as lexing the whole body. lexCache records that state
+// for every line as a checkpoint. An edit is re-lexed from the last
+// clean checkpoint before it, line by line past it, until a line is
+// reached that starts clean both before and after the edit: from
+// there on the old tokens are still right, shifted by the edit.
+
+// checkpoint is the lexer state at the start of a line.
+type checkpoint struct {
+	offset  int  // rune offset of the line start
+	inToken bool // the line starts inside a token
+}
+
+// lexCache holds a window body and its colored regions, kept in step
+// with the window's edit events.
+type lexCache struct {
+	tokenize func(string) []region
+	body     []rune
+	regions  []region     // sorted by runeStart, non-overlapping
+	lines    []checkpoint // one per line, sorted by offset
+
+	// dirty is the range of the body, in current offsets, whose
+	// regions have not yet been written to the spans file.
+	dirty                bool
+	dirtyStart, dirtyEnd int
+}
+
+// newLexCache lexes body in full. The whole body is dirty.
+func newLexCache(tokenize func(string) []region, body string) *lexCache {
+	c := &lexCache{tokenize: tokenize}
+	c.reset(body)
+	return c
+}
+
+// reset replaces the body and lexes it in full. The whole body is
+// dirty.
+func (c *lexCache) reset(body string) {
+	c.body = []rune(body)
+	c.regions = c.tokenize(body)
+	c.lines = checkpoints(c.body, c.regions, 0, len(c.body)+1)
+	c.dirty, c.dirtyStart, c.dirtyEnd = true, 0, len(c.body)
+}
+
+// edit replaces the runes in [q0, q1) with text and re-lexes the
+// lines the change can affect.
+func (c *lexCache) edit(q0, q1 int, text []rune) {
+	delta := len(text) - (q1 - q0)
+	c.body = slices.Replace(c.body, q0, q1, text...)
+
+	// Restart at the last clean line start at or before q0. A lexer
+	// may look one rune past a newline to end a token (LaTeX math stops
+	// at a blank line), so an edit at a line start restarts a line
+	// earlier.
+	i := sort.Search(len(c.lines), func(i int) bool { return c.lines[i].offset > q0 }) - 1
+	if i > 0 && c.lines[i].offset == q0 {
+		i--
+	}
+	for i > 0 && c.lines[i].inToken {
+		i--
+	}
+	restart := c.lines[i].offset
+
+	// Lex up to the start of a line after the edit, doubling the
+	// number of lines each time the states there disagree.
+	j := sort.Search(len(c.lines), func(j int) bool { return c.lines[j].offset > q1 })
+	for n := 1; ; n *= 2 {
+		end := len(c.body)
+		atEOF := j >= len(c.lines)
+		if !atEOF {
+			end = c.lines[j].offset + delta
+		}
+		regs := c.tokenize(string(c.body[restart:end]))
+		for k := range regs {
+			regs[k].runeStart += restart
+			regs[k].runeEnd += restart
+		}
+		// A token reaching end is unterminated within the lexed text
+		// and may continue past it.
+		clean := len(regs) == 0 || regs[len(regs)-1].runeEnd < end
+		if atEOF || (clean && !c.lines[j].inToken) {
+			c.splice(i, j, restart, end, delta, regs)
+			c.markDirty(q0, q1, len(text), restart, end)
+			return
+		}
+		j = min(j+n, len(c.lines))
+	}
+}
+
+// splice replaces the regions and checkpoints between restart and the
+// old checkpoint j (end in the new body) with newly lexed regs, and
+// shifts those after by delta.
+func (c *lexCache) splice(i, j, restart, end, delta int, regs []region) {
+	a := sort.Search(len(c.regions), func(k int) bool { return c.regions[k].runeStart >= restart })
+	b := len(c.regions)
+	lineEnd := len(c.body) + 1
+	var tail []checkpoint
+	if j < len(c.lines) {
+		oldEnd := c.lines[j].offset
+		b = sort.Search(len(c.regions), func(k int) bool { return c.regions[k].runeStart >= oldEnd })
+		lineEnd = end
+		tail = c.lines[j:]
+		for k := range tail {
+			tail[k].offset += delta
+		}
+	}
+	for k := b; k < len(c.regions); k++ {
+		c.regions[k].runeStart += delta
+		c.regions[k].runeEnd += delta
+	}
+	c.regions = slices.Replace(c.regions, a, b, regs...)
+	c.lines = slices.Concat(c.lines[:i], checkpoints(c.body, regs, restart, lineEnd), tail)
+}
+
+// markDirty adds [start, end) to the dirty range after adjusting the
+// range for the replacement of [q0, q1) with n runes.
+func (c *lexCache) markDirty(q0, q1, n, start, end int) {
+	if c.dirty {
+		shift := func(q, inside int) int {
+			switch {
+			case q <= q0:
+				return q
+			case q < q1:
+				return inside
+			}
+			return q + n - (q1 - q0)
+		}
+		start = min(start, shift(c.dirtyStart, q0))
+		end = max(end, shift(c.dirtyEnd, q0+n))
+	}
+	c.dirty, c.dirtyStart, c.dirtyEnd = true, start, end
+}
+
+// takeDirty returns the dirty range and marks the body clean.
+func (c *lexCache) takeDirty() (start, end int, ok bool) {
+	start, end, ok = c.dirtyStart, c.dirtyEnd, c.dirty
+	c.dirty = false
+	return
+}
+
+// checkpoints returns the checkpoints of the line starts in body
+// within [from, to). regions must include every region that covers
+// a line start in that range.
+//
+// A token that ends at a line start, having consumed the newline,
+// counts as covering it: it is usually unterminated at the end of the
+// body, and would continue through text appended there.
+func checkpoints(body []rune, regions []region, from, to int) []checkpoint {
+	var cps []checkpoint
+	r := 0
+	for p := from; p < to && p <= len(body); p++ {
+		if p > 0 && body[p-1] != '\n' {
+			continue
+		}
+		for r < len(regions) && regions[r].runeEnd < p {
+			r++
+		}
+		in := r < len(regions) && regions[r].runeStart < p
+		cps = append(cps, checkpoint{p, in})
+	}
+	return cps
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// checkCache verifies that c matches a full lex of its body.
+func checkCache(t *testing.T, c *lexCache, step string) {
+	t.Helper()
+	full := newLexCache(c.tokenize, string(c.body))
+	if diff := cmp.Diff(full.regions, c.regions, cmp.AllowUnexported(region{}), cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("%s: regions differ from full lex (-full +incremental):\n%s\nbody: %q", step, diff, string(c.body))
+	}
+	if diff := cmp.Diff(full.lines, c.lines, cmp.AllowUnexported(checkpoint{})); diff != "" {
+		t.Fatalf("%s: checkpoints differ from full lex (-full +incremental):\n%s\nbody: %q", step, diff, string(c.body))
+	}
+}
+
+func TestLexCacheEdits(t *testing.T) {
+	const src = "package main\n\nfunc f() {\n\tx := 1\n\ts := `raw\nstring`\n}\n\n// done\n"
+	c := newLexCache(tokenizeGo, src)
+	if start, end, ok := c.takeDirty(); !ok || start != 0 || end != len([]rune(src)) {
+		t.Errorf("new cache dirty = %d, %d, %v; want whole body", start, end, ok)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		q0, q1 int
+		text   string
+	}{
+		{"open block comment", 14, 14, "/*"},
+		{"close block comment", 30, 30, "*/"},
+		{"delete comment opener", 14, 16, ""},
+		{"delete raw string close", 49, 50, ""},
+		{"insert at end", len([]rune(src)), len([]rune(src)), "var y = 2\n"},
+		{"replace across lines", 3, 20, "k"},
+	} {
+		if tc.q1 > len(c.body) {
+			tc.q0, tc.q1 = len(c.body), len(c.body)
+		}
+		c.edit(tc.q0, tc.q1, []rune(tc.text))
+		checkCache(t, c, tc.name)
+	}
+}
+
+func TestLexCacheDirtyRange(t *testing.T) {
+	const src = "a := 1\nb := 2\nc := 3\nd := 4\n"
+	c := newLexCache(tokenizeGo, src)
+	c.takeDirty()
+
+	c.edit(8, 8, []rune("x")) // on line b: re-lexes just that line
+	start, end, ok := c.takeDirty()
+	if !ok || start != 7 || end != 15 {
+		t.Errorf("dirty after one-line edit = %d, %d, %v; want 7, 15, true", start, end, ok)
+	}
+
+	c.edit(0, 0, []rune("/*"))   // unterminated comment: to the end
+	c.edit(22, 22, []rune("yy")) // inside the comment, on line c
+	start, end, ok = c.takeDirty()
+	if !ok || start != 0 || end != len(c.body) {
+		t.Errorf("dirty after comment = %d, %d, %v; want 0, %d, true", start, end, ok, len(c.body))
+	}
+	if _, _, ok := c.takeDirty(); ok {
+		t.Errorf("takeDirty twice reported dirty")
+	}
+}
+
+func TestLexCacheRandomEdits(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		tokenize func(string) []region
+		src      string
+		pieces   []string
+	}{
+		{"go", tokenizeGo, "package p\n\n/* c */\nvar s = `a\nb`\nfunc f() { return \"x\" }\n",
+			[]string{"/*", "*/", "`", "\"", "\n", "//", "func", " ", "1.5", "'a'"}},
+		{"python", tokenizePython, "def f():\n    '''doc\n    more'''\n    return 1  # c\n",
+			[]string{"'''", "\"", "#", "\n", "def", " ", "0x1f", "r'"}},
+		{"rust", tokenizeRust, "fn f<'a>() {\n    /* a /* b */ */\n    let s = r#\"x\n\"#;\n}\n",
+			[]string{"/*", "*/", "r#\"", "\"#", "'", "\n", "let", " "}},
+		{"latex", tokenizeLatex, "\\begin{doc}\n$x\ny$ % c\n$$a\n$$\n\\end{doc}\n",
+			[]string{"$", "$$", "%", "\\\\", "\n", "\n\n", "\\begin{x}", " "}},
+		{"grammar", grammarFor(loadGrammars(""), "a.sh").tokenize,
+			"# c\nif [ \"$x\" ]; then\n\techo 'a\nb' $1\nfi\n",
+			[]string{"\"", "'", "#", "\\", "\n", "$", "if", " "}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			c := newLexCache(tc.tokenize, tc.src)
+			for i := range 500 {
+				q0 := rng.Intn(len(c.body) + 1)
+				q1 := q0
+				if rng.Intn(2) == 0 {
+					q1 = min(len(c.body), q0+rng.Intn(6))
+				}
+				var text []rune
+				if q1 == q0 || rng.Intn(2) == 0 {
+					text = []rune(tc.pieces[rng.Intn(len(tc.pieces))])
+				}
+				c.edit(q0, q1, text)
+				checkCache(t, c, "edit "+string(rune('0'+i%10)))
+			}
+		})
+	}
+}
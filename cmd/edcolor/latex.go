@@ -17,19 +17,7 @@ func tokenizeLatex(src string) []region {
 		if runeEnd <= runeStart {
 			continue
 		}
-		var color string
-		var bold bool
-		switch t.kind {
-		case tokKeyword:
-			color, bold = colorKeyword, true
-		case tokComment:
-			color = colorComment
-		case tokString:
-			color = colorString
-		case tokBuiltin:
-			color = colorBuiltin
-		}
-		regions = append(regions, region{runeStart, runeEnd, color, bold})
+		regions = append(regions, region{runeStart, runeEnd, kindClass(t.kind)})
 	}
 
 	return regions
@@ -106,6 +94,7 @@ func lexLatex(src string) []latexToken {
 		if c == '$' && i+1 < n && src[i+1] == '$' {
 			start := i
 			i += 2
+			closed := false
 			for i+1 < n {
 				if src[i] == '\\' {
 					i += 2 // skip escaped char
@@ -113,12 +102,13 @@ func lexLatex(src string) []latexToken {
 				}
 				if src[i] == '$' && i+1 < n && src[i+1] == '$' {
 					i += 2
+					closed = true
 					break
 				}
 				i++
 			}
-			// Handle unterminated display math at EOF.
-			if i > n {
+			// Unterminated display math runs to EOF.
+			if !closed || i > n {
 				i = n
 			}
 			tokens = append(tokens, latexToken{start, i, tokString})
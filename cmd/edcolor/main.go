@@ -1,6 +1,7 @@
 // Edcolor syntax-colors source files in edwood using the spans file.
 //
-// Usage: set up fileHooks in exec.go to map extensions to "edcolor".
+// Usage: map extensions to "edcolor" in the file hook table (see
+// filehook.go and $HOME/lib/edwood/hooks).
 // Edcolor is invoked automatically when a matching file is opened.
 //
 // Edcolor reads the window tag to determine the filename, selects
@@ -8,9 +9,22 @@
 // body, lexes it, and writes span definitions to the window's spans
 // file. Edwood renders the styled text through its rich.Frame engine.
 //
-// After the initial coloring, edcolor watches for edit events and
-// re-colors with a short debounce delay. It exits when the window
-// is closed or when the file extension has no registered lexer.
+// Go, Python, Rust and LaTeX have hand-written lexers. Other languages
+// (C, shell, YAML, TypeScript and makefiles among the built-ins) are
+// described by declarative grammars, which users can add to in
+// $HOME/lib/edwood/grammars; see grammar.go.
+//
+// Colors come from the theme file, $HOME/lib/edwood/theme or the file
+// named by -theme, which maps token classes (keyword, string, comment,
+// number, builtin, and the scopes of grammars) to styles. Classes it
+// does not mention keep the colors of defaultTheme.
+//
+// After the initial coloring, edcolor watches for edit events. Each
+// edit is re-lexed from the nearest line before it where the lexer
+// state is known (see incremental.go), and after a short debounce
+// only the re-lexed range is written to the spans file. Edcolor exits
+// when the window is closed or when the file extension has no
+// registered lexer.
 //
 // The $winid environment variable (set automatically by edwood for
 // B2 commands) identifies the target window.
@@ -29,18 +43,36 @@ import (
 	"9fans.net/go/acme"
 	"9fans.net/go/plan9"
 	"9fans.net/go/plan9/client"
+	"github.com/rjkroege/edwood/internal/theme"
 )
 
-// Color scheme.
+// Token classes, the names under which the theme styles regions.
 const (
-	colorKeyword   = "#0000cc" // blue
-	colorString    = "#008000" // green
-	colorComment   = "#808080" // gray
-	colorNumber    = "#cc6600" // orange
-	colorBuiltin   = "#008080" // teal
-	colorHighlight = "#f0f4ff" // very light blue background for matching occurrences
+	classKeyword   = "keyword"
+	classString    = "string"
+	classComment   = "comment"
+	classNumber    = "number"
+	classBuiltin   = "builtin"
+	classHighlight = "highlight" // background of occurrences of the selection
 )
 
+// defaultTheme is the color scheme used for classes the user's theme
+// file does not style.
+var defaultTheme = theme.Theme{
+	classKeyword:   {Fg: "#0000cc", Bold: true}, // blue
+	classString:    {Fg: "#008000"},             // green
+	classComment:   {Fg: "#808080"},             // gray
+	classNumber:    {Fg: "#cc6600"},             // orange
+	classBuiltin:   {Fg: "#008080"},             // teal
+	classHighlight: {Bg: "#f0f4ff"},             // very light blue
+
+	// Further scopes used by the built-in grammars.
+	"tag":      {Fg: "#0000cc"}, // keys, targets and other names being defined
+	"constant": {Fg: "#cc6600"},
+	"type":     {Fg: "#008080"},
+	"variable": {Fg: "#008080"},
+}
+
 // lexers maps file extensions to tokenizer functions.
 // Each tokenizer takes source text and returns colored regions.
 var lexers = map[string]func(string) []region{
@@ -55,20 +87,24 @@ var lexers = map[string]func(string) []region{
 type span struct {
 	offset int
 	length int
-	color  string
-	bg     string // highlight background; empty = default
+	color  string // "-" for the default
+	bg     string // empty = default
 	bold   bool
+	italic bool
 }
 
+// region is a token: a range of the body and its class.
 type region struct {
 	runeStart, runeEnd int
-	color              string
-	bold               bool
+	class              string
 }
 
 const version = "edcolor v0.1.0"
 
-var verbose = flag.Bool("v", false, "print version and verbose output")
+var (
+	verbose   = flag.Bool("v", false, "print version and verbose output")
+	themeFile = flag.String("theme", theme.DefaultPath(), "theme file")
+)
 
 func main() {
 	flag.Parse()
@@ -86,6 +122,13 @@ func main() {
 		fatal(fmt.Errorf("open window: %w", err))
 	}
 
+	th := defaultTheme
+	if user, err := theme.Load(*themeFile); err != nil {
+		warn(err)
+	} else {
+		th = th.Overlay(user)
+	}
+
 	// Read the tag to determine the filename and extension.
 	tokenize, ext := lexerForWindow(win)
 	if tokenize == nil {
@@ -106,12 +149,18 @@ func main() {
 		fatal(fmt.Errorf("mount acme: %w", err))
 	}
 
-	lastBody := recolor(win, fsys, id, tokenize, nil, 0, 0)
-	eventLoop(win, fsys, id, tokenize, lastBody, ext)
+	body, err := win.ReadAll("body")
+	if err != nil {
+		fatal(fmt.Errorf("read body: %w", err))
+	}
+	cache := newLexCache(tokenize, string(body))
+	writeDirty(fsys, id, cache, th)
+	eventLoop(win, fsys, id, cache, ext, th)
 }
 
 // lexerForWindow reads the window tag and returns the appropriate
-// tokenizer for the file extension, or nil if none matches.
+// tokenizer for the file, or nil if none matches: a grammar whose
+// file patterns match, or else the lexer for the file extension.
 // It also returns the lowercased file extension.
 func lexerForWindow(win *acme.Win) (func(string) []region, string) {
 	tag, err := win.ReadAll("tag")
@@ -125,39 +174,43 @@ func lexerForWindow(win *acme.Win) (func(string) []region, string) {
 		name = name[:i]
 	}
 	ext := strings.ToLower(filepath.Ext(name))
+	if g := grammarFor(loadGrammars(grammarDir()), name); g != nil {
+		return g.tokenize, ext
+	}
 	return lexers[ext], ext
 }
 
-// recolor reads the body, tokenizes it, and writes span definitions.
-// If highlights is non-empty, match backgrounds are merged into the
-// syntax spans. viewOrg and viewEnd specify the visible viewport in
-// rune offsets; spans are generated only for this region plus a margin.
-// When viewOrg == 0 && viewEnd == 0, the full file is colored.
-// Returns the body text for caching.
-func recolor(win *acme.Win, fsys *client.Fsys, id int, tokenize func(string) []region, highlights [][2]int, viewOrg, viewEnd int) string {
-	body, err := win.ReadAll("body")
-	if err != nil {
-		warn(fmt.Errorf("read body: %w", err))
-		return ""
+// writeDirty writes spans for the cache's dirty range, the part of
+// the body re-lexed since the last write, as a single region update.
+func writeDirty(fsys *client.Fsys, id int, cache *lexCache, th theme.Theme) {
+	start, end, ok := cache.takeDirty()
+	if !ok || start >= end {
+		return
 	}
-	if len(body) == 0 {
-		return ""
+	if err := writeSpans(fsys, id, spansIn(cache.regions, th, start, end)); err != nil {
+		warn(err)
 	}
+}
 
-	src := string(body)
-	spans := colorize(src, tokenize, viewOrg, viewEnd)
-	if len(spans) == 0 {
-		return src
+// writeView writes spans for the visible viewport plus a margin, with
+// the backgrounds of highlights merged in.
+func writeView(win *acme.Win, fsys *client.Fsys, id int, cache *lexCache, th theme.Theme, highlights [][2]int) {
+	org, end, err := readViewport(win)
+	if err != nil {
+		org, end = 0, 0
 	}
-
+	clipStart, clipEnd := clipRange(len(cache.body), org, end)
+	spans := spansIn(cache.regions, th, clipStart, clipEnd)
 	if len(highlights) > 0 {
-		spans = applyHighlights(spans, highlights)
+		hl, _ := th.Lookup(classHighlight)
+		spans = applyHighlights(spans, highlights, hl.Bg)
+	}
+	if len(spans) == 0 {
+		return
 	}
-
 	if err := writeSpans(fsys, id, spans); err != nil {
 		warn(err)
 	}
-	return src
 }
 
 // indentExts lists file extensions that support brace-aware auto-indent.
@@ -166,15 +219,19 @@ var indentExts = map[string]bool{
 	".rs": true,
 }
 
-// eventLoop watches for edit and selection events, re-coloring with debouncing.
-// It exits when the window is closed (event channel closed).
-func eventLoop(win *acme.Win, fsys *client.Fsys, id int, tokenize func(string) []region, lastBody string, ext string) {
+// eventLoop watches for edit and selection events. Each edit is
+// applied to cache, which re-lexes only the lines it affects; the
+// re-lexed range is written after a short debounce. It exits when the
+// window is closed (event channel closed).
+func eventLoop(win *acme.Win, fsys *client.Fsys, id int, cache *lexCache, ext string, th theme.Theme) {
 	events := win.EventChan()
 	var editTimer <-chan time.Time
 	var selTimer <-chan time.Time
 	var lastSel string
 	var lastSelQ0, lastSelQ1 int
 	var highlights [][2]int
+	highlighted := false // highlight backgrounds have been written
+	stale := false       // cache missed an edit; reload the body
 	autoIndent := indentExts[ext]
 
 	for {
@@ -184,38 +241,40 @@ func eventLoop(win *acme.Win, fsys *client.Fsys, id int, tokenize func(string) [
 				return
 			}
 			switch e.C2 {
-			case 'I':
-				if autoIndent && e.C1 == 'K' {
-					handleAutoIndent(win, e)
+			case 'I', 'D':
+				// Body edit — update the cache and schedule writing it.
+				switch {
+				case stale:
+				case e.C2 == 'D':
+					cache.edit(e.Q0, e.Q1, nil)
+				case e.Nr == e.Q1-e.Q0:
+					cache.edit(e.Q0, e.Q0, []rune(string(e.Text)))
+				default:
+					// Long insertions arrive without their text.
+					stale = true
+				}
+				if autoIndent && e.C2 == 'I' && e.C1 == 'K' && !stale {
+					handleAutoIndent(win, e, cache.body)
 				}
-				// Body edit — clear cached state and schedule re-coloring.
-				lastBody = ""
-				lastSel = ""
-				highlights = nil
-				editTimer = time.After(300 * time.Millisecond)
-			case 'D':
-				// Body edit — clear cached state and schedule re-coloring.
-				lastBody = ""
 				lastSel = ""
 				highlights = nil
 				editTimer = time.After(300 * time.Millisecond)
 			case 'S':
 				// Body selection changed.
-				if lastBody == "" {
+				if stale {
 					break
 				}
-				runes := []rune(lastBody)
 				q0, q1 := e.Q0, e.Q1
-				if q0 < 0 || q1 > len(runes) || q0 > q1 {
+				if q0 < 0 || q1 > len(cache.body) || q0 > q1 {
 					break
 				}
-				sel := string(runes[q0:q1])
+				sel := string(cache.body[q0:q1])
 				if utf8.RuneCountInString(sel) >= 2 {
 					if sel != lastSel || q0 != lastSelQ0 || q1 != lastSelQ1 {
 						lastSel = sel
 						lastSelQ0 = q0
 						lastSelQ1 = q1
-						highlights = findMatches(lastBody, sel, q0, q1)
+						highlights = findMatches(cache.body, sel, q0, q1)
 						selTimer = time.After(100 * time.Millisecond)
 					}
 				} else if lastSel != "" {
@@ -236,18 +295,26 @@ func eventLoop(win *acme.Win, fsys *client.Fsys, id int, tokenize func(string) [
 			}
 		case <-editTimer:
 			editTimer = nil
-			org, end, err := readViewport(win)
-			if err != nil {
-				org, end = 0, 0
+			if n, err := readBodyLen(win); stale || (err == nil && n != len(cache.body)) {
+				// The cache missed or misapplied an edit.
+				body, err := win.ReadAll("body")
+				if err != nil {
+					warn(fmt.Errorf("read body: %w", err))
+					break
+				}
+				cache.reset(string(body))
+				stale = false
+			}
+			writeDirty(fsys, id, cache, th)
+			if highlighted {
+				// Edits clear the selection highlights.
+				writeView(win, fsys, id, cache, th, nil)
+				highlighted = false
 			}
-			lastBody = recolor(win, fsys, id, tokenize, nil, org, end)
 		case <-selTimer:
 			selTimer = nil
-			org, end, err := readViewport(win)
-			if err != nil {
-				org, end = 0, 0
-			}
-			recolor(win, fsys, id, tokenize, highlights, org, end)
+			writeView(win, fsys, id, cache, th, highlights)
+			highlighted = len(highlights) > 0
 		}
 	}
 }
@@ -255,20 +322,15 @@ func eventLoop(win *acme.Win, fsys *client.Fsys, id int, tokenize func(string) [
 // handleAutoIndent processes keyboard insert events for brace-aware
 // auto-indentation. On newline, it inserts the previous line's
 // indentation (plus one tab after '{'). On '}', it removes one tab
-// of indentation from the current line.
-func handleAutoIndent(win *acme.Win, e *acme.Event) {
+// of indentation from the current line. runes is the body after the
+// insertion.
+func handleAutoIndent(win *acme.Win, e *acme.Event, runes []rune) {
 	text := string(e.Text)
 
 	if !strings.HasSuffix(text, "\n") && text != "}" {
 		return
 	}
 
-	body, err := win.ReadAll("body")
-	if err != nil {
-		return
-	}
-	runes := []rune(string(body))
-
 	if strings.HasSuffix(text, "\n") {
 		// Newline: insert indentation after the newline.
 		// e.Q0 is where the newline was inserted, e.Q1 is after it.
@@ -344,10 +406,9 @@ func computeIndent(body []rune, pos int) (level int, afterBrace bool) {
 	return
 }
 
-// findMatches finds all rune-offset occurrences of sel in body,
+// findMatches finds all rune-offset occurrences of sel in runes,
 // excluding the selection itself at [selQ0, selQ1).
-func findMatches(body, sel string, selQ0, selQ1 int) [][2]int {
-	runes := []rune(body)
+func findMatches(runes []rune, sel string, selQ0, selQ1 int) [][2]int {
 	selRunes := []rune(sel)
 	selLen := len(selRunes)
 	var matches [][2]int
@@ -371,9 +432,9 @@ func findMatches(body, sel string, selQ0, selQ1 int) [][2]int {
 	return matches
 }
 
-// applyHighlights merges highlight backgrounds into syntax spans.
-// Both spans and highlights must be sorted by offset.
-func applyHighlights(spans []span, highlights [][2]int) []span {
+// applyHighlights merges highlight backgrounds of color bg into syntax
+// spans. Both spans and highlights must be sorted by offset.
+func applyHighlights(spans []span, highlights [][2]int, bg string) []span {
 	if len(highlights) == 0 {
 		return spans
 	}
@@ -405,15 +466,15 @@ func applyHighlights(spans []span, highlights [][2]int) []span {
 
 			// Segment before highlight.
 			if hStart > cursor {
-				result = append(result, span{cursor, hStart - cursor, s.color, s.bg, s.bold})
+				result = append(result, span{cursor, hStart - cursor, s.color, s.bg, s.bold, s.italic})
 			}
 			// Highlighted segment.
-			result = append(result, span{hStart, hEnd - hStart, s.color, colorHighlight, s.bold})
+			result = append(result, span{hStart, hEnd - hStart, s.color, bg, s.bold, s.italic})
 			cursor = hEnd
 		}
 		// Remaining segment after last highlight in this span.
 		if cursor < sEnd {
-			result = append(result, span{cursor, sEnd - cursor, s.color, s.bg, s.bold})
+			result = append(result, span{cursor, sEnd - cursor, s.color, s.bg, s.bold, s.italic})
 		}
 	}
 	return result
@@ -427,6 +488,19 @@ func getWinID() (int, error) {
 	return strconv.Atoi(s)
 }
 
+// readBodyLen reads the number of runes in the body from the ctl file.
+func readBodyLen(win *acme.Win) (int, error) {
+	data, err := win.ReadAll("ctl")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return 0, fmt.Errorf("ctl missing body length")
+	}
+	return strconv.Atoi(fields[2])
+}
+
 // readViewport reads the viewport range from the ctl file.
 // Returns (0, 0) if the viewport fields are missing or unparseable.
 func readViewport(win *acme.Win) (org, end int, err error) {
@@ -468,17 +542,17 @@ func writeSpans(fsys *client.Fsys, id int, spans []span) error {
 
 	var buf strings.Builder
 	for _, s := range spans {
-		var line string
-		switch {
-		case s.bg != "" && s.bold:
-			line = fmt.Sprintf("s %d %d %s %s bold\n", s.offset, s.length, s.color, s.bg)
-		case s.bg != "":
-			line = fmt.Sprintf("s %d %d %s %s\n", s.offset, s.length, s.color, s.bg)
-		case s.bold:
-			line = fmt.Sprintf("s %d %d %s bold\n", s.offset, s.length, s.color)
-		default:
-			line = fmt.Sprintf("s %d %d %s\n", s.offset, s.length, s.color)
+		line := fmt.Sprintf("s %d %d %s", s.offset, s.length, s.color)
+		if s.bg != "" {
+			line += " " + s.bg
+		}
+		if s.bold {
+			line += " bold"
+		}
+		if s.italic {
+			line += " italic"
 		}
+		line += "\n"
 
 		if buf.Len()+len(line) > maxChunk && buf.Len() > 0 {
 			if _, err := fid.Write([]byte(buf.String())); err != nil {
@@ -499,19 +573,24 @@ func writeSpans(fsys *client.Fsys, id int, spans []span) error {
 }
 
 // colorize tokenizes src using the given tokenizer and returns
-// contiguous spans covering the visible region, with colors for
-// syntactic elements and default color ("-") for everything else.
+// contiguous spans covering the visible region, with the default
+// theme's colors for syntactic elements and default color ("-") for
+// everything else.
 // When viewOrg == 0 && viewEnd == 0, spans cover the entire file
 // (used for the initial full-file coloring). Otherwise, spans are
 // generated only for the viewport region plus a margin of 1x the
 // viewport size above and below.
 func colorize(src string, tokenize func(string) []region, viewOrg, viewEnd int) []span {
-	totalRunes := utf8.RuneCountInString(src)
-	regions := tokenize(src)
+	clipStart, clipEnd := clipRange(utf8.RuneCountInString(src), viewOrg, viewEnd)
+	return spansIn(tokenize(src), defaultTheme, clipStart, clipEnd)
+}
 
-	// Determine the clip range.
-	clipStart := 0
-	clipEnd := totalRunes
+// clipRange returns the range to color for a viewport: the whole
+// body when viewOrg == 0 && viewEnd == 0, otherwise the viewport plus
+// a margin of 1x its size above and below.
+func clipRange(totalRunes, viewOrg, viewEnd int) (clipStart, clipEnd int) {
+	clipStart = 0
+	clipEnd = totalRunes
 	if viewOrg != 0 || viewEnd != 0 {
 		// Apply margin: 1x viewport size above and below.
 		margin := viewEnd - viewOrg
@@ -524,8 +603,13 @@ func colorize(src string, tokenize func(string) []region, viewOrg, viewEnd int)
 			clipEnd = totalRunes
 		}
 	}
+	return clipStart, clipEnd
+}
 
-	// Build contiguous spans covering the clip range.
+// spansIn returns contiguous spans covering [clipStart, clipEnd),
+// styled by th for the classes of regions (which must be sorted) and
+// default ("-") elsewhere.
+func spansIn(regions []region, th theme.Theme, clipStart, clipEnd int) []span {
 	var spans []span
 	cursor := clipStart
 	for _, r := range regions {
@@ -547,13 +631,18 @@ func colorize(src string, tokenize func(string) []region, viewOrg, viewEnd int)
 		}
 
 		if rs > cursor {
-			spans = append(spans, span{cursor, rs - cursor, "-", "", false})
+			spans = append(spans, span{cursor, rs - cursor, "-", "", false, false})
+		}
+		st, _ := th.Lookup(r.class)
+		fg := st.Fg
+		if fg == "" {
+			fg = "-"
 		}
-		spans = append(spans, span{rs, re - rs, r.color, "", r.bold})
+		spans = append(spans, span{rs, re - rs, fg, st.Bg, st.Bold, st.Italic})
 		cursor = re
 	}
 	if cursor < clipEnd {
-		spans = append(spans, span{cursor, clipEnd - cursor, "-", "", false})
+		spans = append(spans, span{cursor, clipEnd - cursor, "-", "", false, false})
 	}
 
 	return spans
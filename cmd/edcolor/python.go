@@ -53,21 +53,7 @@ func tokenizePython(src string) []region {
 		if runeEnd <= runeStart {
 			continue
 		}
-		var color string
-		var bold bool
-		switch t.kind {
-		case tokKeyword:
-			color, bold = colorKeyword, true
-		case tokComment:
-			color = colorComment
-		case tokString:
-			color = colorString
-		case tokNumber:
-			color = colorNumber
-		case tokBuiltin:
-			color = colorBuiltin
-		}
-		regions = append(regions, region{runeStart, runeEnd, color, bold})
+		regions = append(regions, region{runeStart, runeEnd, kindClass(t.kind)})
 	}
 
 	return regions
@@ -83,6 +69,23 @@ const (
 	tokBuiltin
 )
 
+// kindClass returns the class of a token kind.
+func kindClass(kind int) string {
+	switch kind {
+	case tokKeyword:
+		return classKeyword
+	case tokComment:
+		return classComment
+	case tokString:
+		return classString
+	case tokNumber:
+		return classNumber
+	case tokBuiltin:
+		return classBuiltin
+	}
+	return ""
+}
+
 type pyToken struct {
 	start, end int // byte offsets
 	kind       int
@@ -52,21 +52,7 @@ func tokenizeRust(src string) []region {
 		if runeEnd <= runeStart {
 			continue
 		}
-		var color string
-		var bold bool
-		switch t.kind {
-		case tokKeyword:
-			color, bold = colorKeyword, true
-		case tokComment:
-			color = colorComment
-		case tokString:
-			color = colorString
-		case tokNumber:
-			color = colorNumber
-		case tokBuiltin:
-			color = colorBuiltin
-		}
-		regions = append(regions, region{runeStart, runeEnd, color, bold})
+		regions = append(regions, region{runeStart, runeEnd, kindClass(t.kind)})
 	}
 
 	return regions
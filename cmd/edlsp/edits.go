@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// editor is the part of *acme.Win that applyEdits uses. Tests
+// substitute a fake that edits a string.
+type editor interface {
+	Addr(format string, args ...interface{}) error
+	Ctl(format string, args ...interface{}) error
+	Write(file string, b []byte) (int, error)
+}
+
+// runeEdit is a TextEdit resolved to body rune offsets.
+type runeEdit struct {
+	q0, q1 int
+	text   string
+}
+
+// resolveEdits converts edits against text to rune offsets, ordered
+// last first so that applying them in turn leaves the offsets of the
+// remaining edits valid.
+func resolveEdits(text string, edits []TextEdit) []runeEdit {
+	re := make([]runeEdit, 0, len(edits))
+	for _, e := range edits {
+		re = append(re, runeEdit{
+			q0:   runeOffset(text, e.Range.Start),
+			q1:   runeOffset(text, e.Range.End),
+			text: e.NewText,
+		})
+	}
+	sort.SliceStable(re, func(i, j int) bool {
+		return re[i].q0 > re[j].q0
+	})
+	return re
+}
+
+// applyEdits makes edits to the window body through its addr and data
+// files, as a single change for Undo, the way an Edit command would.
+func applyEdits(w editor, edits []runeEdit) error {
+	if err := w.Ctl("nomark"); err != nil {
+		return err
+	}
+	defer w.Ctl("mark")
+	for _, e := range edits {
+		if err := w.Addr("#%d,#%d", e.q0, e.q1); err != nil {
+			return fmt.Errorf("set addr #%d,#%d: %w", e.q0, e.q1, err)
+		}
+		if _, err := w.Write("data", []byte(e.text)); err != nil {
+			return fmt.Errorf("write data: %w", err)
+		}
+	}
+	return nil
+}
+
+// squiggleColors are the span colors used to underline diagnostics,
+// matching edwood's scrollbar marker colors.
+var squiggleColors = map[string]string{
+	"error":   "#dd0000",
+	"warning": "#ee8800",
+	"info":    "#000099",
+	"hint":    "#888888",
+}
+
+// diagnosticLines formats diags against text as lines for edwood's
+// diagnostics file and, for each, a span that draws a squiggle under
+// its range. Multi-line messages are joined onto one line.
+func diagnosticLines(text string, diags []Diagnostic) (diagLines, spanLines []string) {
+	n := len([]rune(text))
+	for _, d := range diags {
+		q0 := runeOffset(text, d.Range.Start)
+		q1 := max(q0, runeOffset(text, d.Range.End))
+		sev, ok := severityNames[d.Severity]
+		if !ok {
+			sev = "error"
+		}
+		msg := strings.Join(strings.Fields(d.Message), " ")
+		if d.Source != "" {
+			msg = d.Source + ": " + msg
+		}
+		diagLines = append(diagLines, fmt.Sprintf("%d %d %s %s\n", q0, q1-q0, sev, msg))
+
+		// A squiggle under nothing would be invisible.
+		if q1 == q0 {
+			if q0 == n {
+				continue
+			}
+			q1++
+		}
+		spanLines = append(spanLines, fmt.Sprintf("s %d %d - squiggle=%s\n", q0, q1-q0, squiggleColors[sev]))
+	}
+	return diagLines, spanLines
+}
+
+// textSource returns document text by path. The edited window's body
+// is used for its own file; other files are read from disk.
+type textSource func(path string) ([]rune, error)
+
+// locationLines formats locs as addresses that edwood can open with
+// B3, followed by the text of the line each is on. Paths inside dir
+// are made relative to it. Locations are sorted by path and offset.
+func locationLines(locs []Location, dir string, texts textSource) []string {
+	type ref struct {
+		path   string
+		q0, q1 int
+		line   string
+	}
+	var refs []ref
+	for _, l := range locs {
+		path := uriToPath(l.URI)
+		text, err := texts(path)
+		if err != nil {
+			refs = append(refs, ref{path: path, q0: -1})
+			continue
+		}
+		s := string(text)
+		q0, q1 := runeOffset(s, l.Range.Start), runeOffset(s, l.Range.End)
+		refs = append(refs, ref{path, q0, q1, strings.TrimSpace(lineAt(text, q0))})
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].path != refs[j].path {
+			return refs[i].path < refs[j].path
+		}
+		return refs[i].q0 < refs[j].q0
+	})
+
+	lines := make([]string, 0, len(refs))
+	for _, r := range refs {
+		path := r.path
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		if r.q0 < 0 {
+			lines = append(lines, path+"\n")
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s:#%d,#%d: %s\n", path, r.q0, r.q1, r.line))
+	}
+	return lines
+}
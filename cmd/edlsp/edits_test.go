@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeEditor applies addr and data writes to a rune buffer, the way a
+// window body would, and records ctl messages.
+type fakeEditor struct {
+	body   []rune
+	q0, q1 int
+	ctl    []string
+}
+
+func (e *fakeEditor) Addr(format string, args ...interface{}) error {
+	_, err := fmt.Sscanf(fmt.Sprintf(format, args...), "#%d,#%d", &e.q0, &e.q1)
+	return err
+}
+
+func (e *fakeEditor) Ctl(format string, args ...interface{}) error {
+	e.ctl = append(e.ctl, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (e *fakeEditor) Write(file string, b []byte) (int, error) {
+	if file != "data" {
+		return 0, fmt.Errorf("write to %s", file)
+	}
+	r := []rune(string(b))
+	e.body = append(e.body[:e.q0], append(r, e.body[e.q1:]...)...)
+	e.q1 = e.q0 + len(r)
+	return len(b), nil
+}
+
+func TestApplyEdits(t *testing.T) {
+	const text = "var x = 1\nfunc f() { x++ }\n"
+	edits := []TextEdit{
+		{Range{Position{0, 4}, Position{0, 5}}, "count"},
+		{Range{Position{1, 11}, Position{1, 12}}, "count"},
+	}
+	e := &fakeEditor{body: []rune(text)}
+	if err := applyEdits(e, resolveEdits(text, edits)); err != nil {
+		t.Fatalf("applyEdits failed: %v", err)
+	}
+	if got, want := string(e.body), "var count = 1\nfunc f() { count++ }\n"; got != want {
+		t.Errorf("body = %q; want %q", got, want)
+	}
+	if diff := cmp.Diff([]string{"nomark", "mark"}, e.ctl); diff != "" {
+		t.Errorf("ctl mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiagnosticLines(t *testing.T) {
+	const text = "package a\n\nx := y\n"
+	diags := []Diagnostic{
+		{Range: Range{Position{2, 5}, Position{2, 6}}, Severity: 1, Source: "compiler", Message: "undefined: y"},
+		{Range: Range{Position{0, 8}, Position{0, 8}}, Severity: 4, Message: "multi\n  line"},
+		{Range: Range{Position{3, 0}, Position{3, 0}}, Message: "at end"},
+	}
+	diagLines, spanLines := diagnosticLines(text, diags)
+	if diff := cmp.Diff([]string{
+		"16 1 error compiler: undefined: y\n",
+		"8 0 hint multi line\n",
+		"18 0 error at end\n",
+	}, diagLines); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{
+		"s 16 1 - squiggle=#dd0000\n",
+		"s 8 1 - squiggle=#888888\n",
+	}, spanLines); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLocationLines(t *testing.T) {
+	texts := map[string]string{
+		"/src/a.go":     "package a\n\nfunc F() {}\n",
+		"/src/sub/b.go": "package sub\n\tF()\n",
+	}
+	source := func(path string) ([]rune, error) {
+		s, ok := texts[path]
+		if !ok {
+			return nil, fmt.Errorf("no file %s", path)
+		}
+		return []rune(s), nil
+	}
+	locs := []Location{
+		{"file:///src/sub/b.go", Range{Position{1, 1}, Position{1, 2}}},
+		{"file:///src/a.go", Range{Position{2, 5}, Position{2, 6}}},
+		{"file:///other/c.go", Range{}},
+	}
+	want := []string{
+		"/other/c.go\n",
+		"a.go:#16,#17: func F() {}\n",
+		"sub/b.go:#13,#14: F()\n",
+	}
+	if diff := cmp.Diff(want, locationLines(locs, "/src", source)); diff != "" {
+		t.Errorf("locationLines mismatch (-want +got):\n%s", diff)
+	}
+}
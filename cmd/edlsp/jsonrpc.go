@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// message is a JSON-RPC 2.0 request, notification or response. A
+// request has both ID and Method, a notification only Method, and a
+// response only ID.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// errClosed is returned for calls outstanding when the server's
+// output ends.
+var errClosed = errors.New("connection closed")
+
+// Conn is a JSON-RPC connection using the LSP base protocol framing
+// (Content-Length headers). Server notifications and server-to-client
+// requests are passed to handle, which runs on the read goroutine and
+// returns the result for requests.
+type Conn struct {
+	wmu sync.Mutex
+	w   io.Writer
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *message
+	err     error // set once the read loop exits
+
+	handle func(method string, params json.RawMessage) any
+	done   chan struct{}
+}
+
+// NewConn starts a connection reading from r and writing to w.
+func NewConn(r io.Reader, w io.Writer, handle func(method string, params json.RawMessage) any) *Conn {
+	c := &Conn{
+		w:       w,
+		pending: make(map[int64]chan *message),
+		handle:  handle,
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(r))
+	return c
+}
+
+// Done is closed when the read side of the connection ends.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Call sends a request and decodes its result into result, which may
+// be nil to discard it.
+func (c *Conn) Call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	rawID := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.send(&message{ID: &rawID, Method: method}, params); err != nil {
+		return err
+	}
+
+	select {
+	case m := <-ch:
+		if m == nil {
+			return c.err
+		}
+		if m.Error != nil {
+			return fmt.Errorf("%s: %w", method, m.Error)
+		}
+		if result == nil || len(m.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(m.Result, result); err != nil {
+			return fmt.Errorf("%s: decode result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+// Notify sends a notification.
+func (c *Conn) Notify(method string, params any) error {
+	return c.send(&message{Method: method}, params)
+}
+
+// send fills in m's parameters and writes it with its header.
+func (c *Conn) send(m *message, params any) error {
+	m.JSONRPC = "2.0"
+	if params != nil {
+		p, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("%s: encode params: %w", m.Method, err)
+		}
+		m.Params = p
+	}
+	return c.write(m)
+}
+
+func (c *Conn) write(m *message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(body)
+	return err
+}
+
+func (c *Conn) readLoop(r *bufio.Reader) {
+	var err error
+	for {
+		var m *message
+		m, err = readMessage(r)
+		if err != nil {
+			break
+		}
+		switch {
+		case m.Method != "" && m.ID != nil:
+			c.reply(m)
+		case m.Method != "":
+			if c.handle != nil {
+				c.handle(m.Method, m.Params)
+			}
+		case m.ID != nil:
+			id, perr := strconv.ParseInt(string(*m.ID), 10, 64)
+			if perr != nil {
+				continue
+			}
+			c.mu.Lock()
+			ch := c.pending[id]
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		}
+	}
+
+	if err == io.EOF {
+		err = errClosed
+	}
+	c.mu.Lock()
+	c.err = err
+	for id, ch := range c.pending {
+		ch <- nil
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// reply answers a server-to-client request with the handler's result.
+// Servers block on some of these (workspace/configuration), so every
+// request gets a response, even if it is null. The response is written
+// from its own goroutine: the server may itself be blocked writing to
+// us, and the read loop must keep draining its output.
+func (c *Conn) reply(req *message) {
+	var result any
+	if c.handle != nil {
+		result = c.handle(req.Method, req.Params)
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		raw = []byte("null")
+	}
+	go c.write(&message{JSONRPC: "2.0", ID: req.ID, Result: raw})
+}
+
+// readMessage reads one framed message.
+func readMessage(r *bufio.Reader) (*message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("bad Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	var m message
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	return &m, nil
+}
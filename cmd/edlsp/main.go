@@ -0,0 +1,493 @@
+// Edlsp connects an edwood window to a Language Server Protocol
+// server such as gopls or pyright.
+//
+// Sibling of cmd/edcolor: run it from a window's tag as a B2 command
+// (so $winid is set), e.g. "edlsp hover". Each invocation starts the
+// server for the window's file type, sends it the current body
+// (including unsaved changes), performs one action and shuts the
+// server down. Edlsp does not read the window's event file, so it
+// works alongside edcolor and md2spans.
+//
+// See the usage text below for the actions.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9"
+	"9fans.net/go/plan9/client"
+	"9fans.net/go/plumb"
+)
+
+const version = "edlsp v0.1.0"
+
+const usage = `usage: edlsp [-h] [-v] [-server cmd] [-t timeout] action [arg]
+
+Runs a language server for the file in the window identified by
+$winid (set by edwood when edlsp is launched as a B2 command) and
+performs one action at the window's selection:
+
+  hover        print documentation for the selected symbol
+  def          plumb the definition of the selected symbol
+  refs         list references in the +References window
+  rename NEW   rename the selected symbol to NEW everywhere
+  diag         write the server's diagnostics to the window's
+               diagnostics file and squiggle their ranges
+
+  -h           print this help and exit
+  -v           print version; pass through server stderr
+  -server cmd  language server command (default by extension)
+  -t timeout   give up after timeout (default 30s)
+`
+
+// servers maps file extensions to the language ID and default server
+// command for files of that type.
+var servers = map[string]struct {
+	languageID string
+	argv       []string
+}{
+	".go":  {"go", []string{"gopls"}},
+	".py":  {"python", []string{"pyright-langserver", "--stdio"}},
+	".pyi": {"python", []string{"pyright-langserver", "--stdio"}},
+}
+
+// rootMarkers are files whose presence marks a directory as the root
+// of a workspace.
+var rootMarkers = []string{"go.work", "go.mod", "pyproject.toml", "setup.py", ".git"}
+
+// diagSettle is how long diag waits for further diagnostics after a
+// set is published.
+var diagSettle = 500 * time.Millisecond
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// options are the parsed command line.
+type options struct {
+	action  string
+	arg     string
+	server  []string
+	timeout time.Duration
+	verbose bool
+}
+
+// run is the testable core of main. Return values: 0 success, 1
+// runtime/environment error, 2 invocation error (bad args).
+func run(argv []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("edlsp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	help := fs.Bool("h", false, "print help and exit")
+	verbose := fs.Bool("v", false, "print version and server stderr")
+	server := fs.String("server", "", "language server command")
+	timeout := fs.Duration("t", 30*time.Second, "timeout")
+	if err := fs.Parse(argv); err != nil {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if *help {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	opts := options{
+		action:  fs.Arg(0),
+		arg:     fs.Arg(1),
+		server:  strings.Fields(*server),
+		timeout: *timeout,
+		verbose: *verbose,
+	}
+	nargs := 1
+	switch opts.action {
+	case "hover", "def", "refs", "diag":
+	case "rename":
+		nargs = 2
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if fs.NArg() != nargs {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if opts.verbose {
+		fmt.Fprintln(stderr, version)
+	}
+
+	winidStr := getenv("winid")
+	if winidStr == "" {
+		fmt.Fprintf(stderr, "edlsp: $winid is not set; launch from edwood as a B2 command\n")
+		return 1
+	}
+	winid, err := strconv.Atoi(winidStr)
+	if err != nil {
+		fmt.Fprintf(stderr, "edlsp: $winid is not an integer (%q): %v\n", winidStr, err)
+		return 1
+	}
+
+	if err := attach(winid, opts, stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "edlsp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// target is the window edlsp was run in and the server's view of it.
+type target struct {
+	win   *acme.Win
+	winid int
+	name  string // file name from the tag
+	dir   string // directory of name
+	body  string
+	q0    int // start of dot
+	uri   string
+}
+
+// text returns the contents of the file at path: the window's body
+// for its own file, otherwise the file on disk.
+func (t *target) text(path string) ([]rune, error) {
+	if path == t.name {
+		return []rune(t.body), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return []rune(string(b)), nil
+}
+
+// attach opens the window, starts its language server and performs
+// the requested action.
+func attach(winid int, opts options, stdout, stderr io.Writer) error {
+	win, err := acme.Open(winid, nil)
+	if err != nil {
+		return fmt.Errorf("open window %d: %w", winid, err)
+	}
+	defer win.CloseFiles()
+
+	t := &target{win: win, winid: winid}
+	tag, err := win.ReadAll("tag")
+	if err != nil {
+		return fmt.Errorf("read tag: %w", err)
+	}
+	t.name = string(tag)
+	if i := strings.IndexAny(t.name, " \t"); i >= 0 {
+		t.name = t.name[:i]
+	}
+	if !filepath.IsAbs(t.name) {
+		return fmt.Errorf("window %d is not a file: %q", winid, t.name)
+	}
+	t.dir = filepath.Dir(t.name)
+	t.uri = pathToURI(t.name)
+
+	ext := strings.ToLower(filepath.Ext(t.name))
+	lang, ok := servers[ext]
+	argv := lang.argv
+	if len(opts.server) > 0 {
+		argv = opts.server
+		if !ok {
+			lang.languageID = strings.TrimPrefix(ext, ".")
+		}
+	} else if !ok {
+		return fmt.Errorf("no language server for %s; use -server", filepath.Base(t.name))
+	}
+
+	body, err := win.ReadAll("body")
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	t.body = string(body)
+	if err := win.Ctl("addr=dot"); err != nil {
+		return fmt.Errorf("read dot: %w", err)
+	}
+	if t.q0, _, err = win.ReadAddr(); err != nil {
+		return fmt.Errorf("read dot: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	serverStderr := io.Discard
+	if opts.verbose {
+		serverStderr = stderr
+	}
+	s, err := Start(ctx, argv, findRoot(t.dir), serverStderr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		s.Close(ctx)
+	}()
+	if err := s.Open(t.uri, lang.languageID, t.body); err != nil {
+		return err
+	}
+
+	pos := position(t.body, t.q0)
+	switch opts.action {
+	case "hover":
+		text, err := s.Hover(ctx, t.uri, pos)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			return fmt.Errorf("no hover information")
+		}
+		fmt.Fprintln(stdout, text)
+	case "def":
+		locs, err := s.Definition(ctx, t.uri, pos)
+		if err != nil {
+			return err
+		}
+		return showDefinition(t, locs, stdout)
+	case "refs":
+		locs, err := s.References(ctx, t.uri, pos)
+		if err != nil {
+			return err
+		}
+		if len(locs) == 0 {
+			return fmt.Errorf("no references found")
+		}
+		return showWindow(filepath.Join(t.dir, "+References"), locationLines(locs, t.dir, t.text))
+	case "rename":
+		edits, err := s.Rename(ctx, t.uri, pos, opts.arg)
+		if err != nil {
+			return err
+		}
+		return rename(t, edits)
+	case "diag":
+		diags, err := s.Diagnostics(ctx, t.uri, diagSettle)
+		if err != nil {
+			return err
+		}
+		return writeDiagnostics(t, diags)
+	}
+	return nil
+}
+
+// findRoot returns the nearest directory at or above dir containing
+// one of rootMarkers, or dir if there is none.
+func findRoot(dir string) string {
+	for d := dir; ; {
+		for _, m := range rootMarkers {
+			if _, err := os.Stat(filepath.Join(d, m)); err == nil {
+				return d
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// showDefinition plumbs the first of locs to the editor. Without a
+// plumber, it prints the address for the user to B3 instead.
+func showDefinition(t *target, locs []Location, stdout io.Writer) error {
+	if len(locs) == 0 {
+		return fmt.Errorf("no definition found")
+	}
+	path := uriToPath(locs[0].URI)
+	text, err := t.text(path)
+	if err != nil {
+		return err
+	}
+	s := string(text)
+	addr := fmt.Sprintf("#%d,#%d", runeOffset(s, locs[0].Range.Start), runeOffset(s, locs[0].Range.End))
+
+	if err := plumbAddr(t.dir, path, addr); err != nil {
+		fmt.Fprintf(stdout, "%s:%s\n", path, addr)
+	}
+	return nil
+}
+
+// plumbAddr sends path and addr to the plumber's edit port.
+func plumbAddr(dir, path, addr string) error {
+	fid, err := plumb.Open("send", plan9.OWRITE)
+	if err != nil {
+		return err
+	}
+	defer fid.Close()
+	m := &plumb.Message{
+		Src:  "edlsp",
+		Dst:  "edit",
+		Dir:  dir,
+		Type: "text",
+		Attr: &plumb.Attribute{Name: "addr", Value: addr},
+		Data: []byte(path),
+	}
+	return m.Send(fid)
+}
+
+// lookupWindow returns the window with the given name, or nil.
+func lookupWindow(name string) (*acme.Win, error) {
+	wins, err := acme.Windows()
+	if err != nil {
+		return nil, err
+	}
+	for _, wi := range wins {
+		if wi.Name == name {
+			return acme.Open(wi.ID, nil)
+		}
+	}
+	return nil, nil
+}
+
+// showWindow replaces the body of the window called name with lines,
+// creating the window if needed.
+func showWindow(name string, lines []string) error {
+	w, err := lookupWindow(name)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		if w, err = acme.New(); err != nil {
+			return fmt.Errorf("new window: %w", err)
+		}
+		if err := w.Name("%s", name); err != nil {
+			return err
+		}
+	}
+	defer w.CloseFiles()
+
+	if err := w.Addr(","); err != nil {
+		return err
+	}
+	if _, err := w.Write("data", []byte(strings.Join(lines, ""))); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	return w.Ctl("show")
+}
+
+// rename applies the edits of a rename to every affected file. Files
+// without a window are opened in a new one and left modified for the
+// user to Put.
+func rename(t *target, edits map[string][]TextEdit) error {
+	if len(edits) == 0 {
+		return fmt.Errorf("nothing to rename")
+	}
+	uris := make([]string, 0, len(edits))
+	for uri := range edits {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		path := uriToPath(uri)
+		if path == t.name {
+			if err := applyEdits(t.win, resolveEdits(t.body, edits[uri])); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			continue
+		}
+		if err := renameIn(path, edits[uri]); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// renameIn applies edits to the window for path, opening it if needed.
+func renameIn(path string, edits []TextEdit) error {
+	w, err := lookupWindow(path)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		if w, err = acme.New(); err != nil {
+			return fmt.Errorf("new window: %w", err)
+		}
+		if err := w.Name("%s", path); err != nil {
+			return err
+		}
+		if err := w.Ctl("get"); err != nil {
+			return err
+		}
+	}
+	defer w.CloseFiles()
+
+	body, err := w.ReadAll("body")
+	if err != nil {
+		return err
+	}
+	return applyEdits(w, resolveEdits(string(body), edits))
+}
+
+// writeDiagnostics replaces the window's diagnostics with diags and
+// squiggles their ranges. The squiggles are ordinary spans, so a
+// colorer restyling the window (edcolor after an edit) removes them;
+// the diagnostics themselves follow edits.
+func writeDiagnostics(t *target, diags []Diagnostic) error {
+	diagLines, spanLines := diagnosticLines(t.body, diags)
+
+	fsys, err := client.MountService("acme")
+	if err != nil {
+		return fmt.Errorf("mount acme: %w", err)
+	}
+	fid, err := fsys.Open(fmt.Sprintf("%d/diagnostics", t.winid), plan9.OWRITE)
+	if err != nil {
+		return fmt.Errorf("open diagnostics: %w", err)
+	}
+	defer fid.Close()
+	if _, err := fid.Write([]byte("c\n")); err != nil {
+		return fmt.Errorf("write diagnostics: %w", err)
+	}
+	if err := writeChunked(fid, diagLines); err != nil {
+		return fmt.Errorf("write diagnostics: %w", err)
+	}
+
+	if len(spanLines) == 0 {
+		return nil
+	}
+	sfid, err := fsys.Open(fmt.Sprintf("%d/spans", t.winid), plan9.OWRITE)
+	if err != nil {
+		return fmt.Errorf("open spans: %w", err)
+	}
+	defer sfid.Close()
+	// Spans in one write must be contiguous, so each squiggle is a
+	// write of its own.
+	for _, l := range spanLines {
+		if _, err := sfid.Write([]byte(l)); err != nil {
+			return fmt.Errorf("write spans: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeChunked writes complete lines to w in writes that stay within
+// 9P message size limits.
+func writeChunked(w io.Writer, lines []string) error {
+	const maxChunk = 4000
+
+	var buf strings.Builder
+	for _, line := range lines {
+		if buf.Len()+len(line) > maxChunk && buf.Len() > 0 {
+			if _, err := io.WriteString(w, buf.String()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+		buf.WriteString(line)
+	}
+	if buf.Len() > 0 {
+		if _, err := io.WriteString(w, buf.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
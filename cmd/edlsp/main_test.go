@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// envFunc builds a minimal env-getter from a map for tests.
+func envFunc(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestRunRejectsBadArgs(t *testing.T) {
+	for _, argv := range [][]string{
+		nil,
+		{"--bogus"},
+		{"jump"},
+		{"hover", "extra"},
+		{"rename"},
+	} {
+		var stderr bytes.Buffer
+		if code := run(argv, envFunc(nil), io.Discard, &stderr); code != 2 {
+			t.Errorf("run(%q) returned %d; want 2", argv, code)
+		}
+		if !strings.Contains(stderr.String(), "usage") {
+			t.Errorf("run(%q) stderr did not mention usage; got %q", argv, stderr.String())
+		}
+	}
+}
+
+func TestRunHelpExitsZero(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-h"}, envFunc(nil), &stdout, &stderr); code != 0 {
+		t.Errorf("run -h returned %d; want 0", code)
+	}
+	if !strings.Contains(stdout.String(), "usage") {
+		t.Errorf("stdout did not contain usage; got %q", stdout.String())
+	}
+}
+
+func TestRunMissingWinidExitsOne(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{"rename", "New"}, envFunc(nil), io.Discard, &stderr); code != 1 {
+		t.Errorf("run without $winid returned %d; want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "winid") {
+		t.Errorf("stderr did not mention winid; got %q", stderr.String())
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"unicode/utf16"
+)
+
+// LSP positions count UTF-16 code units within a line; edwood
+// addresses count runes from the start of the body. These helpers
+// convert between the two.
+
+// runeOffset returns the rune offset in text of p. A character past
+// the end of its line clamps to the line's end and a line past the
+// end of text clamps to the end of text.
+func runeOffset(text string, p Position) int {
+	q, line, col := 0, 0, 0
+	for _, r := range text {
+		if line == p.Line {
+			if r == '\n' || col >= p.Character {
+				return q
+			}
+			col += utf16.RuneLen(r)
+		}
+		if r == '\n' {
+			line++
+		}
+		q++
+	}
+	return q
+}
+
+// position returns the Position of rune offset q in text. An offset
+// past the end of text is the end of text.
+func position(text string, q int) Position {
+	var p Position
+	i := 0
+	for _, r := range text {
+		if i == q {
+			break
+		}
+		if r == '\n' {
+			p.Line++
+			p.Character = 0
+		} else {
+			p.Character += utf16.RuneLen(r)
+		}
+		i++
+	}
+	return p
+}
+
+// lineAt returns the text of the line containing rune offset q,
+// without its newline.
+func lineAt(text []rune, q int) string {
+	q = max(0, min(q, len(text)))
+	start, end := q, q
+	for start > 0 && text[start-1] != '\n' {
+		start--
+	}
+	for end < len(text) && text[end] != '\n' {
+		end++
+	}
+	return string(text[start:end])
+}
+
+// pathToURI returns the file URI naming the absolute path.
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// uriToPath returns the path named by a file URI.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
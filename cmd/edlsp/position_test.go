@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestPositionRoundTrip(t *testing.T) {
+	// 'é' is one UTF-16 unit; '𝄞' is two.
+	const text = "ab\nxé𝄞y\n\nz"
+	for _, tc := range []struct {
+		q   int
+		pos Position
+	}{
+		{0, Position{0, 0}},
+		{2, Position{0, 2}},
+		{3, Position{1, 0}},
+		{5, Position{1, 2}},
+		{6, Position{1, 4}},
+		{7, Position{1, 5}},
+		{8, Position{2, 0}},
+		{9, Position{3, 0}},
+		{10, Position{3, 1}},
+	} {
+		if got := position(text, tc.q); got != tc.pos {
+			t.Errorf("position(%d) = %v; want %v", tc.q, got, tc.pos)
+		}
+		if got := runeOffset(text, tc.pos); got != tc.q {
+			t.Errorf("runeOffset(%v) = %d; want %d", tc.pos, got, tc.q)
+		}
+	}
+}
+
+func TestRuneOffsetClamps(t *testing.T) {
+	const text = "ab\ncd"
+	for _, tc := range []struct {
+		pos  Position
+		want int
+	}{
+		{Position{0, 10}, 2},
+		{Position{5, 0}, 5},
+	} {
+		if got := runeOffset(text, tc.pos); got != tc.want {
+			t.Errorf("runeOffset(%v) = %d; want %d", tc.pos, got, tc.want)
+		}
+	}
+}
+
+func TestURIPath(t *testing.T) {
+	const path = "/home/me/my project/a.go"
+	uri := pathToURI(path)
+	if uri != "file:///home/me/my%20project/a.go" {
+		t.Errorf("pathToURI(%q) = %q", path, uri)
+	}
+	if got := uriToPath(uri); got != path {
+		t.Errorf("uriToPath(%q) = %q; want %q", uri, got, path)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// The subset of the Language Server Protocol types edlsp uses. Field
+// names follow the specification.
+
+// Position is a zero-based line and UTF-16 code unit offset.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a half-open range of Positions.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a Range in the document named by URI.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextEdit replaces Range with NewText.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// Diagnostic is a message from the server about a Range.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// severityNames maps LSP DiagnosticSeverity values to the severity
+// names of edwood's diagnostics file. A missing severity is an error.
+var severityNames = map[int]string{
+	0: "error",
+	1: "error",
+	2: "warning",
+	3: "info",
+	4: "hint",
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type referenceParams struct {
+	textDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type renameParams struct {
+	textDocumentPositionParams
+	NewName string `json:"newName"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// workspaceEdit is the result of textDocument/rename. Servers send
+// either changes or documentChanges; resource operations (file
+// creation and renaming) in documentChanges are not supported and
+// have no edits.
+type workspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes"`
+	DocumentChanges []struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+		Edits        []TextEdit             `json:"edits"`
+	} `json:"documentChanges"`
+}
+
+// edits returns the edits in w grouped by document URI.
+func (w *workspaceEdit) edits() map[string][]TextEdit {
+	edits := make(map[string][]TextEdit)
+	for uri, e := range w.Changes {
+		edits[uri] = append(edits[uri], e...)
+	}
+	for _, dc := range w.DocumentChanges {
+		if dc.TextDocument.URI != "" {
+			edits[dc.TextDocument.URI] = append(edits[dc.TextDocument.URI], dc.Edits...)
+		}
+	}
+	return edits
+}
+
+// locationOrLink decodes either a Location or a LocationLink, the
+// two element types a definition result may hold.
+type locationOrLink struct {
+	URI                  string `json:"uri"`
+	Range                Range  `json:"range"`
+	TargetURI            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+func (l locationOrLink) location() Location {
+	if l.TargetURI != "" {
+		return Location{URI: l.TargetURI, Range: l.TargetSelectionRange}
+	}
+	return Location{URI: l.URI, Range: l.Range}
+}
+
+// decodeLocations decodes a Location, a list of Locations or a list
+// of LocationLinks.
+func decodeLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []locationOrLink
+	if raw[0] != '[' {
+		var one locationOrLink
+		if err := json.Unmarshal(raw, &one); err != nil {
+			return nil, err
+		}
+		list = append(list, one)
+	} else if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	locs := make([]Location, 0, len(list))
+	for _, l := range list {
+		locs = append(locs, l.location())
+	}
+	return locs, nil
+}
+
+// hoverText extracts the text of a Hover result's contents, which
+// may be a MarkupContent, a MarkedString or a list of MarkedStrings.
+func hoverText(raw json.RawMessage) string {
+	var h struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &h) != nil || len(h.Contents) == 0 {
+		return ""
+	}
+	var parts []json.RawMessage
+	if h.Contents[0] == '[' {
+		if json.Unmarshal(h.Contents, &parts) != nil {
+			return ""
+		}
+	} else {
+		parts = []json.RawMessage{h.Contents}
+	}
+	var texts []string
+	for _, p := range parts {
+		var s string
+		if json.Unmarshal(p, &s) == nil {
+			texts = append(texts, s)
+			continue
+		}
+		var v struct {
+			Value string `json:"value"`
+		}
+		if json.Unmarshal(p, &v) == nil {
+			texts = append(texts, v.Value)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n\n"))
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Session is a connection to a running language server.
+type Session struct {
+	conn *Conn
+	cmd  *exec.Cmd // nil when the server was not started by Start
+	in   io.Closer // the server's stdin
+
+	mu     sync.Mutex
+	diags  map[string][]Diagnostic // latest published diagnostics by URI
+	update chan string             // URIs of publishDiagnostics notifications
+}
+
+// Start runs the server command argv in root with its standard input
+// and output connected to a Session, and initializes it with root as
+// the workspace. The server's standard error goes to stderr.
+func Start(ctx context.Context, argv []string, root string, stderr io.Writer) (*Session, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = root
+	cmd.Stderr = stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", argv[0], err)
+	}
+	s := newSession(out, in)
+	s.cmd = cmd
+	if err := s.initialize(ctx, root); err != nil {
+		s.Close(ctx)
+		return nil, err
+	}
+	return s, nil
+}
+
+// newSession returns a Session speaking to a server that reads w and
+// writes r. It does not initialize the server.
+func newSession(r io.Reader, w io.WriteCloser) *Session {
+	s := &Session{
+		in:     w,
+		diags:  make(map[string][]Diagnostic),
+		update: make(chan string, 16),
+	}
+	s.conn = NewConn(r, w, s.handle)
+	return s
+}
+
+// handle processes messages sent by the server.
+func (s *Session) handle(method string, params json.RawMessage) any {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var p publishDiagnosticsParams
+		if json.Unmarshal(params, &p) != nil {
+			return nil
+		}
+		s.mu.Lock()
+		s.diags[p.URI] = p.Diagnostics
+		s.mu.Unlock()
+		select {
+		case s.update <- p.URI:
+		default:
+		}
+	case "workspace/configuration":
+		// One (null) setting per requested item: use the defaults.
+		var p struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(params, &p)
+		return make([]any, len(p.Items))
+	}
+	return nil
+}
+
+func (s *Session) initialize(ctx context.Context, root string) error {
+	rootURI := pathToURI(root)
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"clientInfo": map[string]string{
+			"name":    "edlsp",
+			"version": version,
+		},
+		"rootUri": rootURI,
+		"workspaceFolders": []map[string]string{
+			{"uri": rootURI, "name": filepath.Base(root)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"hover":              map[string]any{"contentFormat": []string{"plaintext"}},
+				"definition":         map[string]any{"linkSupport": true},
+				"references":         map[string]any{},
+				"rename":             map[string]any{},
+				"publishDiagnostics": map[string]any{},
+			},
+			"workspace": map[string]any{
+				"configuration": true,
+				"workspaceEdit": map[string]any{"documentChanges": true},
+			},
+		},
+	}
+	if err := s.conn.Call(ctx, "initialize", params, nil); err != nil {
+		return err
+	}
+	return s.conn.Notify("initialized", struct{}{})
+}
+
+// Open tells the server the contents of the document uri, which
+// may differ from the file on disk.
+func (s *Session) Open(uri, languageID, text string) error {
+	return s.conn.Notify("textDocument/didOpen", map[string]any{
+		"textDocument": textDocumentItem{
+			URI:        uri,
+			LanguageID: languageID,
+			Version:    1,
+			Text:       text,
+		},
+	})
+}
+
+func positionParams(uri string, pos Position) textDocumentPositionParams {
+	return textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: uri},
+		Position:     pos,
+	}
+}
+
+// Hover returns the hover text at pos in uri, or "" if there is none.
+func (s *Session) Hover(ctx context.Context, uri string, pos Position) (string, error) {
+	var raw json.RawMessage
+	if err := s.conn.Call(ctx, "textDocument/hover", positionParams(uri, pos), &raw); err != nil {
+		return "", err
+	}
+	return hoverText(raw), nil
+}
+
+// Definition returns the locations of the definition of the symbol at
+// pos in uri.
+func (s *Session) Definition(ctx context.Context, uri string, pos Position) ([]Location, error) {
+	var raw json.RawMessage
+	if err := s.conn.Call(ctx, "textDocument/definition", positionParams(uri, pos), &raw); err != nil {
+		return nil, err
+	}
+	return decodeLocations(raw)
+}
+
+// References returns the locations of the references to the symbol
+// at pos in uri, including its declaration.
+func (s *Session) References(ctx context.Context, uri string, pos Position) ([]Location, error) {
+	p := referenceParams{textDocumentPositionParams: positionParams(uri, pos)}
+	p.Context.IncludeDeclaration = true
+	var locs []Location
+	if err := s.conn.Call(ctx, "textDocument/references", p, &locs); err != nil {
+		return nil, err
+	}
+	return locs, nil
+}
+
+// Rename returns the edits, grouped by document URI, that rename the
+// symbol at pos in uri to newName.
+func (s *Session) Rename(ctx context.Context, uri string, pos Position, newName string) (map[string][]TextEdit, error) {
+	p := renameParams{textDocumentPositionParams: positionParams(uri, pos), NewName: newName}
+	var we workspaceEdit
+	if err := s.conn.Call(ctx, "textDocument/rename", p, &we); err != nil {
+		return nil, err
+	}
+	return we.edits(), nil
+}
+
+// Diagnostics waits for the server to publish diagnostics for uri and
+// returns the latest set once none has arrived for settle. Servers
+// often publish an early partial set followed by the full one. If ctx
+// ends after some set has arrived, that set is returned.
+func (s *Session) Diagnostics(ctx context.Context, uri string, settle time.Duration) ([]Diagnostic, error) {
+	var quiet <-chan time.Time
+	s.mu.Lock()
+	_, seen := s.diags[uri]
+	s.mu.Unlock()
+	if seen {
+		quiet = time.After(settle)
+	}
+	for {
+		select {
+		case u := <-s.update:
+			if u == uri {
+				seen = true
+				quiet = time.After(settle)
+			}
+		case <-quiet:
+			return s.published(uri), nil
+		case <-ctx.Done():
+			if seen {
+				return s.published(uri), nil
+			}
+			return nil, fmt.Errorf("no diagnostics published: %w", ctx.Err())
+		case <-s.conn.Done():
+			if seen {
+				return s.published(uri), nil
+			}
+			return nil, errClosed
+		}
+	}
+}
+
+func (s *Session) published(uri string) []Diagnostic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.diags[uri]
+}
+
+// Close shuts the server down and waits for it to exit. A server that
+// does not exit when asked is killed.
+func (s *Session) Close(ctx context.Context) error {
+	err := s.conn.Call(ctx, "shutdown", nil, nil)
+	if err == nil {
+		err = s.conn.Notify("exit", nil)
+	}
+	s.in.Close()
+	if s.cmd == nil {
+		return err
+	}
+	// Wait requires that reads from the server's output are done.
+	select {
+	case <-s.conn.Done():
+	case <-time.After(2 * time.Second):
+		s.cmd.Process.Kill()
+	}
+	s.cmd.Wait()
+	return err
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeServer is an in-process language server speaking the base
+// protocol over pipes. It answers each request method with a canned
+// result and publishes diagnostics when a document is opened.
+type fakeServer struct {
+	t       *testing.T
+	results map[string]any
+	diags   []publishDiagnosticsParams
+
+	mu       sync.Mutex
+	requests map[string]json.RawMessage // params of the last message per method
+	configOK bool                       // workspace/configuration was answered
+}
+
+// startFake connects a new Session to a fakeServer. The Session is
+// not initialized.
+func startFake(t *testing.T, results map[string]any, diags ...publishDiagnosticsParams) (*Session, *fakeServer) {
+	t.Helper()
+	toServerR, toServerW := io.Pipe()
+	toClientR, toClientW := io.Pipe()
+	f := &fakeServer{
+		t:        t,
+		results:  results,
+		diags:    diags,
+		requests: make(map[string]json.RawMessage),
+	}
+	go f.serve(toServerR, toClientW)
+	t.Cleanup(func() {
+		toServerW.Close()
+		toClientW.Close()
+	})
+	return newSession(toClientR, toServerW), f
+}
+
+func (f *fakeServer) serve(r io.Reader, w io.WriteCloser) {
+	defer w.Close()
+	br := bufio.NewReader(r)
+	send := func(m *message) {
+		m.JSONRPC = "2.0"
+		body, _ := json.Marshal(m)
+		io.WriteString(w, "Content-Length: "+strconv.Itoa(len(body))+"\r\n\r\n")
+		w.Write(body)
+	}
+	configID := json.RawMessage(`"cfg"`)
+	for {
+		m, err := readMessage(br)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		if m.Method != "" {
+			f.requests[m.Method] = m.Params
+		} else if m.ID != nil && string(*m.ID) == string(configID) {
+			f.configOK = string(m.Result) == "[null,null]"
+		}
+		f.mu.Unlock()
+
+		switch {
+		case m.Method == "exit":
+			return
+		case m.Method == "initialized":
+			send(&message{ID: &configID, Method: "workspace/configuration",
+				Params: json.RawMessage(`{"items":[{},{}]}`)})
+		case m.Method == "textDocument/didOpen":
+			for _, d := range f.diags {
+				p, _ := json.Marshal(d)
+				send(&message{Method: "textDocument/publishDiagnostics", Params: p})
+			}
+		case m.Method != "" && m.ID != nil:
+			res, _ := json.Marshal(f.results[m.Method])
+			send(&message{ID: m.ID, Result: res})
+		}
+	}
+}
+
+func (f *fakeServer) params(method string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return string(f.requests[method])
+}
+
+func TestSessionRequests(t *testing.T) {
+	loc := Location{URI: "file:///src/a.go", Range: Range{Position{1, 2}, Position{1, 5}}}
+	s, f := startFake(t, map[string]any{
+		"initialize": map[string]any{"capabilities": map[string]any{}},
+		"textDocument/hover": map[string]any{
+			"contents": map[string]string{"kind": "plaintext", "value": "func F()\n"},
+		},
+		"textDocument/definition": []map[string]any{
+			{"targetUri": loc.URI, "targetSelectionRange": loc.Range, "targetRange": Range{}},
+		},
+		"textDocument/references": []Location{loc, loc},
+		"textDocument/rename": map[string]any{
+			"documentChanges": []map[string]any{{
+				"textDocument": map[string]any{"uri": loc.URI, "version": 1},
+				"edits":        []TextEdit{{Range: loc.Range, NewText: "G"}},
+			}},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.initialize(ctx, "/src"); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	if err := s.Open(loc.URI, "go", "package a\n"); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	hover, err := s.Hover(ctx, loc.URI, Position{1, 3})
+	if err != nil || hover != "func F()" {
+		t.Errorf("Hover = %q, %v; want %q", hover, err, "func F()")
+	}
+	if got, want := f.params("textDocument/hover"), `{"textDocument":{"uri":"file:///src/a.go"},"position":{"line":1,"character":3}}`; got != want {
+		t.Errorf("hover params = %s; want %s", got, want)
+	}
+
+	def, err := s.Definition(ctx, loc.URI, Position{})
+	if err != nil {
+		t.Fatalf("Definition: %v", err)
+	}
+	if diff := cmp.Diff([]Location{loc}, def); diff != "" {
+		t.Errorf("Definition mismatch (-want +got):\n%s", diff)
+	}
+
+	refs, err := s.References(ctx, loc.URI, Position{})
+	if err != nil {
+		t.Fatalf("References: %v", err)
+	}
+	if len(refs) != 2 {
+		t.Errorf("References returned %d locations; want 2", len(refs))
+	}
+
+	edits, err := s.Rename(ctx, loc.URI, Position{}, "G")
+	if err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if diff := cmp.Diff(map[string][]TextEdit{loc.URI: {{loc.Range, "G"}}}, edits); diff != "" {
+		t.Errorf("Rename mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := s.Close(ctx); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.configOK {
+		t.Errorf("workspace/configuration request was not answered with one null per item")
+	}
+}
+
+func TestSessionDiagnostics(t *testing.T) {
+	const uri = "file:///src/a.go"
+	early := publishDiagnosticsParams{URI: uri}
+	full := publishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{
+		{Range: Range{Position{0, 0}, Position{0, 7}}, Severity: 1, Message: "bad"},
+	}}
+	other := publishDiagnosticsParams{URI: "file:///src/b.go", Diagnostics: full.Diagnostics}
+	s, _ := startFake(t, nil, early, other, full)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Open(uri, "go", "package a\n"); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, err := s.Diagnostics(ctx, uri, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Diagnostics: %v", err)
+	}
+	if diff := cmp.Diff(full.Diagnostics, got); diff != "" {
+		t.Errorf("Diagnostics mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSessionDiagnosticsTimeout(t *testing.T) {
+	s, _ := startFake(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.Diagnostics(ctx, "file:///src/a.go", time.Millisecond); err == nil {
+		t.Errorf("Diagnostics with nothing published succeeded; want error")
+	}
+}
+
+func TestDecodeLocations(t *testing.T) {
+	want := []Location{{URI: "file:///a.go", Range: Range{Position{1, 2}, Position{1, 3}}}}
+	for _, raw := range []string{
+		`{"uri":"file:///a.go","range":{"start":{"line":1,"character":2},"end":{"line":1,"character":3}}}`,
+		`[{"uri":"file:///a.go","range":{"start":{"line":1,"character":2},"end":{"line":1,"character":3}}}]`,
+		`[{"targetUri":"file:///a.go","targetSelectionRange":{"start":{"line":1,"character":2},"end":{"line":1,"character":3}}}]`,
+	} {
+		got, err := decodeLocations(json.RawMessage(raw))
+		if err != nil {
+			t.Errorf("decodeLocations(%s) failed: %v", raw, err)
+			continue
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("decodeLocations(%s) mismatch (-want +got):\n%s", raw, diff)
+		}
+	}
+	if got, err := decodeLocations(json.RawMessage("null")); err != nil || got != nil {
+		t.Errorf("decodeLocations(null) = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestHoverText(t *testing.T) {
+	for _, tc := range []struct {
+		raw, want string
+	}{
+		{`{"contents":{"kind":"plaintext","value":"doc"}}`, "doc"},
+		{`{"contents":"doc"}`, "doc"},
+		{`{"contents":[{"language":"go","value":"func F()"},"doc"]}`, "func F()\n\ndoc"},
+		{`null`, ""},
+	} {
+		if got := hoverText(json.RawMessage(tc.raw)); got != tc.want {
+			t.Errorf("hoverText(%s) = %q; want %q", tc.raw, got, tc.want)
+		}
+	}
+}
@@ -16,10 +16,11 @@ import (
 //
 // Format per line:
 //
-//	s <offset> <length> <fg> [flags...]
+//	s <offset> <length> <fg> [<bg>] [flags...]
 //
 // where <fg> is the Span's Fg field (e.g. "#0000cc") or "-" for
-// "use the default". Flags are "bold" and/or "italic". One `s`
+// "use the default", and <bg> is the Span's Bg field, omitted when
+// empty. Flags are "bold" and/or "italic". One `s`
 // line per emitted span; no leading `c\n` (the writer issues the
 // clear as a separate Twrite — see writeSpans in main.go).
 //
@@ -128,7 +129,7 @@ func writeEndRegionLine(b *strings.Builder) {
 	b.WriteString("end region\n")
 }
 
-// writeSpanLine emits one `s OFFSET LENGTH FG flags...`
+// writeSpanLine emits one `s OFFSET LENGTH FG [BG] flags...`
 // line for a non-box styled run.
 func writeSpanLine(b *strings.Builder, s Span) {
 	fg := s.Fg
@@ -136,6 +137,9 @@ func writeSpanLine(b *strings.Builder, s Span) {
 		fg = "-"
 	}
 	fmt.Fprintf(b, "s %d %d %s", s.Offset, s.Length, fg)
+	if s.Bg != "" {
+		fmt.Fprintf(b, " %s", s.Bg)
+	}
 	writeStyleFlags(b, s)
 	b.WriteByte('\n')
 }
@@ -150,9 +154,12 @@ func writeBoxLine(b *strings.Builder, s Span) {
 	if fg == "" {
 		fg = "-"
 	}
-	// Box format requires the BG slot. md2spans emits "-"
-	// for default; future producers may set Bg.
-	fmt.Fprintf(b, "b %d %d %d %d %s -", s.Offset, s.Length, s.BoxWidth, s.BoxHeight, fg)
+	// Box format requires the BG slot; "-" is the default.
+	bg := s.Bg
+	if bg == "" {
+		bg = "-"
+	}
+	fmt.Fprintf(b, "b %d %d %d %d %s %s", s.Offset, s.Length, s.BoxWidth, s.BoxHeight, fg, bg)
 	writeStyleFlags(b, s)
 	if s.BoxPlacement != "" {
 		fmt.Fprintf(b, " placement=%s", s.BoxPlacement)
@@ -260,7 +267,7 @@ func isDefaultFill(s Span) bool {
 	if s.Kind != SpanStyled {
 		return false
 	}
-	return s.Fg == "" && !s.Bold && !s.Italic && s.Scale == 0 &&
+	return s.Fg == "" && s.Bg == "" && !s.Bold && !s.Italic && s.Scale == 0 &&
 		s.Family == "" && !s.HRule
 }
 
@@ -301,6 +308,7 @@ func fillGaps(styled []Span, totalRunes int) []Span {
 			Offset:       start,
 			Length:       end - start,
 			Fg:           s.Fg,
+			Bg:           s.Bg,
 			Bold:         s.Bold,
 			Italic:       s.Italic,
 			Scale:        s.Scale,
@@ -24,9 +24,10 @@ import (
 	"9fans.net/go/acme"
 	"9fans.net/go/plan9"
 	"9fans.net/go/plan9/client"
+	"github.com/rjkroege/edwood/internal/theme"
 )
 
-const usage = `usage: md2spans [-h] [-once]
+const usage = `usage: md2spans [-h] [-once] [-theme file]
 
 Reads markdown from the window identified by $winid (set by
 edwood when md2spans is launched as a B2 command), parses it,
@@ -38,6 +39,8 @@ current body and exits.
 
   -h        print this help and exit
   -once     render once and exit; do not watch for edits
+  -theme    theme file to color links, code and headings
+            (default $HOME/lib/edwood/theme)
 `
 
 // editDebounce is the delay between a body edit and the next
@@ -68,6 +71,7 @@ func run(argv []string, getenv func(string) string, stdout, stderr io.Writer) in
 	fs.SetOutput(stderr)
 	help := fs.Bool("h", false, "print help and exit")
 	once := fs.Bool("once", false, "render once and exit")
+	themeFile := fs.String("theme", theme.DefaultPath(), "theme file")
 	if err := fs.Parse(argv); err != nil {
 		fmt.Fprint(stderr, usage)
 		return 2
@@ -94,7 +98,14 @@ func run(argv []string, getenv func(string) string, stdout, stderr io.Writer) in
 		return 1
 	}
 
-	if err := attachAndRender(winid, *once, stderr); err != nil {
+	user, err := theme.Load(*themeFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "md2spans: %v\n", err)
+		return 1
+	}
+	th := defaultTheme.Overlay(user)
+
+	if err := attachAndRender(winid, *once, th, stderr); err != nil {
 		fmt.Fprintf(stderr, "md2spans: %v\n", err)
 		return 1
 	}
@@ -133,7 +144,7 @@ func (o fsysOpener) OpenSpans(winid int) (io.WriteCloser, error) {
 // The *acme.Win is released via win.CloseFiles before return.
 // *client.Fsys has no explicit close in 9fans.net/go/plan9/client;
 // its underlying connection lives until the process exits.
-func attachAndRender(winid int, once bool, stderr io.Writer) error {
+func attachAndRender(winid int, once bool, th theme.Theme, stderr io.Writer) error {
 	win, err := acme.Open(winid, nil)
 	if err != nil {
 		return fmt.Errorf("open window %d: %w", winid, err)
@@ -156,7 +167,7 @@ func attachAndRender(winid int, once bool, stderr io.Writer) error {
 	}
 	opener := fsysOpener{fsys: fsys}
 
-	if err := renderOnce(win, opener, winid); err != nil {
+	if err := renderOnce(win, opener, winid, th); err != nil {
 		fmt.Fprintf(stderr, "md2spans: render: %v\n", err)
 		// Continue to watch loop anyway; transient errors shouldn't
 		// take down the watcher.
@@ -177,12 +188,12 @@ func attachAndRender(winid int, once bool, stderr io.Writer) error {
 		fmt.Fprintf(stderr, "md2spans: ctl menu: %v\n", err)
 	}
 
-	watchEdits(win, opener, winid, stderr)
+	watchEdits(win, opener, winid, th, stderr)
 	return nil
 }
 
-// renderOnce reads the body, parses it, and writes the
-// spans-protocol output to the window's spans file.
+// renderOnce reads the body, parses it, styles it with th, and
+// writes the spans-protocol output to the window's spans file.
 //
 // Race note: the body can change between ReadAll and the spans
 // write. Spans are computed against the pre-edit body; if the
@@ -192,13 +203,13 @@ func attachAndRender(winid int, once bool, stderr io.Writer) error {
 // v1 accepts the transient mismatch; an interlock here would
 // require coordination with edwood's event stream that doesn't
 // fit the v1 architecture.
-func renderOnce(reader bodyReader, opener spansOpener, winid int) error {
+func renderOnce(reader bodyReader, opener spansOpener, winid int, th theme.Theme) error {
 	body, err := reader.ReadAll("body")
 	if err != nil {
 		return fmt.Errorf("read body: %w", err)
 	}
 	src := string(body)
-	spans := Parse(src)
+	spans := applyTheme(Parse(src), th)
 	totalRunes := utf8.RuneCountInString(src)
 	return writeSpans(opener, winid, spans, totalRunes)
 }
@@ -218,7 +229,7 @@ func renderOnce(reader bodyReader, opener spansOpener, winid int) error {
 // allocated a fresh runtime timer for every edit, leaving the
 // prior one to expire and be GC'd. Reset reuses the timer's
 // underlying state.
-func watchEdits(win *acme.Win, opener spansOpener, winid int, stderr io.Writer) {
+func watchEdits(win *acme.Win, opener spansOpener, winid int, th theme.Theme, stderr io.Writer) {
 	events := win.EventChan()
 	editTimer := newStoppedTimer()
 	defer editTimer.Stop()
@@ -238,7 +249,7 @@ func watchEdits(win *acme.Win, opener spansOpener, winid int, stderr io.Writer)
 				win.WriteEvent(e)
 			}
 		case <-editTimer.C:
-			if err := renderOnce(win, opener, winid); err != nil {
+			if err := renderOnce(win, opener, winid, th); err != nil {
 				fmt.Fprintf(stderr, "md2spans: render after edit: %v\n", err)
 			}
 		}
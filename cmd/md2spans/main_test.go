@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -78,3 +80,20 @@ func TestRunBadWinidExitsOne(t *testing.T) {
 		t.Errorf("run with non-integer $winid returned %d, want 1", code)
 	}
 }
+
+// TestRunBadThemeExitsOne covers -theme: a theme file that does not
+// parse is reported before md2spans attaches to the window.
+func TestRunBadThemeExitsOne(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "theme")
+	if err := os.WriteFile(path, []byte("markdown.link blue\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	code := run([]string{"-theme", path}, envFunc(map[string]string{"winid": "1"}), io.Discard, &stderr)
+	if code != 1 {
+		t.Errorf("run with a bad theme returned %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), path) {
+		t.Errorf("stderr did not name the theme file; got %q", stderr.String())
+	}
+}
@@ -51,6 +51,9 @@ type Span struct {
 	// Fg is a CSS-style hex color "#rrggbb" or "" for "use the
 	// default foreground" (renders as "-" in the spans protocol).
 	Fg string
+	// Bg is the background color in the same form. The parser
+	// leaves it empty; only the theme (applyTheme) sets it.
+	Bg string
 	// Bold and Italic are flag bits. Both may be set
 	// simultaneously (bold-italic).
 	Bold   bool
@@ -80,7 +80,7 @@ func TestRenderOnceEmptyBody(t *testing.T) {
 	file := &fakeSpansFile{failOn: -1}
 	opener := &fakeSpansOpener{file: file}
 
-	if err := renderOnce(reader, opener, 1); err != nil {
+	if err := renderOnce(reader, opener, 1, defaultTheme); err != nil {
 		t.Fatalf("renderOnce: %v", err)
 	}
 	if got := file.allWritten(); got != "c\n" {
@@ -99,7 +99,7 @@ func TestRenderOncePlainBody(t *testing.T) {
 	file := &fakeSpansFile{failOn: -1}
 	opener := &fakeSpansOpener{file: file}
 
-	if err := renderOnce(reader, opener, 1); err != nil {
+	if err := renderOnce(reader, opener, 1, defaultTheme); err != nil {
 		t.Fatalf("renderOnce: %v", err)
 	}
 	got := file.allWritten()
@@ -124,7 +124,7 @@ func TestRenderOnceStyledBody(t *testing.T) {
 	file := &fakeSpansFile{failOn: -1}
 	opener := &fakeSpansOpener{file: file}
 
-	if err := renderOnce(reader, opener, 1); err != nil {
+	if err := renderOnce(reader, opener, 1, defaultTheme); err != nil {
 		t.Fatalf("renderOnce: %v", err)
 	}
 	got := file.allWritten()
@@ -143,7 +143,7 @@ func TestRenderOnceUTF8(t *testing.T) {
 	file := &fakeSpansFile{failOn: -1}
 	opener := &fakeSpansOpener{file: file}
 
-	if err := renderOnce(reader, opener, 1); err != nil {
+	if err := renderOnce(reader, opener, 1, defaultTheme); err != nil {
 		t.Fatalf("renderOnce: %v", err)
 	}
 	got := file.allWritten()
@@ -163,7 +163,7 @@ func TestRenderOnceReadBodyFails(t *testing.T) {
 	reader := fakeBodyReader{err: errors.New("read failed")}
 	opener := &fakeSpansOpener{file: &fakeSpansFile{failOn: -1}}
 
-	err := renderOnce(reader, opener, 1)
+	err := renderOnce(reader, opener, 1, defaultTheme)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -177,7 +177,7 @@ func TestRenderOnceOpenSpansFails(t *testing.T) {
 	reader := fakeBodyReader{body: []byte("hello")}
 	opener := &fakeSpansOpener{openErr: errors.New("9P open failed")}
 
-	err := renderOnce(reader, opener, 1)
+	err := renderOnce(reader, opener, 1, defaultTheme)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -193,7 +193,7 @@ func TestRenderOnceClearWriteFails(t *testing.T) {
 	file := &fakeSpansFile{failOn: 0, failErr: errors.New("9P write failed")}
 	opener := &fakeSpansOpener{file: file}
 
-	err := renderOnce(reader, opener, 1)
+	err := renderOnce(reader, opener, 1, defaultTheme)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -210,7 +210,7 @@ func TestRenderOnceSpansWriteFails(t *testing.T) {
 	file := &fakeSpansFile{failOn: 1, failErr: errors.New("9P write failed")}
 	opener := &fakeSpansOpener{file: file}
 
-	err := renderOnce(reader, opener, 1)
+	err := renderOnce(reader, opener, 1, defaultTheme)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -0,0 +1,67 @@
+package main
+
+import "github.com/rjkroege/edwood/internal/theme"
+
+// Theme classes. The parser marks spans by their attributes rather
+// than by class, so spanClasses recovers the classes from those.
+const (
+	classHeading  = "markdown.heading"  // ATX heading content (Scale set)
+	classCode     = "markdown.code"     // inline code and code-block bodies
+	classLink     = "markdown.link"     // inline link text
+	classEmphasis = "markdown.emphasis" // italic runs
+	classStrong   = "markdown.strong"   // bold runs
+)
+
+// defaultTheme styles the classes the user's theme does not.
+var defaultTheme = theme.Theme{
+	classLink: {Fg: linkBlue},
+}
+
+// spanClasses returns the classes of a styled span, most general
+// first, so that the styles of later classes win.
+func spanClasses(s Span) []string {
+	var classes []string
+	if s.Scale != 0 {
+		classes = append(classes, classHeading)
+	}
+	if s.Bold {
+		classes = append(classes, classStrong)
+	}
+	if s.Italic {
+		classes = append(classes, classEmphasis)
+	}
+	if s.Family == "code" {
+		classes = append(classes, classCode)
+	}
+	if s.Fg == linkBlue {
+		classes = append(classes, classLink)
+	}
+	return classes
+}
+
+// applyTheme restyles the styled spans in spans by their classes in
+// th. A class's colors replace the span's; its bold and italic flags
+// are added to the span's. Box and region spans are left alone.
+func applyTheme(spans []Span, th theme.Theme) []Span {
+	for i := range spans {
+		s := &spans[i]
+		if s.Kind != SpanStyled || s.HRule {
+			continue
+		}
+		for _, class := range spanClasses(*s) {
+			st, ok := th.Lookup(class)
+			if !ok {
+				continue
+			}
+			if st.Fg != "" {
+				s.Fg = st.Fg
+			}
+			if st.Bg != "" {
+				s.Bg = st.Bg
+			}
+			s.Bold = s.Bold || st.Bold
+			s.Italic = s.Italic || st.Italic
+		}
+	}
+	return spans
+}
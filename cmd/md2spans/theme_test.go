@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rjkroege/edwood/internal/theme"
+)
+
+func TestApplyTheme(t *testing.T) {
+	th := defaultTheme.Overlay(theme.Theme{
+		classLink:          {Fg: "#66aaff", Bold: true},
+		classCode:          {Fg: "#aa0000", Bg: "#eeeeee"},
+		classEmphasis:      {Fg: "#00aa00"},
+		"markdown.heading": {Italic: true},
+	})
+	// An H1 heading holding a link, some code, plain text, an italic
+	// run, and an image box.
+	spans := []Span{
+		{Offset: 0, Length: 2, Scale: 2},
+		{Offset: 2, Length: 4, Scale: 2, Fg: linkBlue},
+		{Offset: 7, Length: 3, Family: "code"},
+		{Offset: 10, Length: 3, Italic: true},
+		{Kind: SpanBox, Offset: 13, BoxPayload: "image:a.png"},
+	}
+	want := []Span{
+		{Offset: 0, Length: 2, Scale: 2, Italic: true},
+		{Offset: 2, Length: 4, Scale: 2, Fg: "#66aaff", Bold: true, Italic: true},
+		{Offset: 7, Length: 3, Family: "code", Fg: "#aa0000", Bg: "#eeeeee"},
+		{Offset: 10, Length: 3, Italic: true, Fg: "#00aa00"},
+		{Kind: SpanBox, Offset: 13, BoxPayload: "image:a.png"},
+	}
+	if diff := cmp.Diff(want, applyTheme(spans, th)); diff != "" {
+		t.Errorf("applyTheme mismatch (-want +got):\n%s", diff)
+	}
+
+	got := FormatSpans(want[2:3], 10)
+	if wantLine := "s 0 7 -\ns 7 3 #aa0000 #eeeeee family=code\n"; got != wantLine {
+		t.Errorf("FormatSpans = %q, want %q", got, wantLine)
+	}
+}
+
+func TestDefaultThemeKeepsLinkColor(t *testing.T) {
+	spans := applyTheme(Parse("see [docs](http://x)\n"), defaultTheme)
+	if len(spans) != 1 || spans[0].Fg != linkBlue || spans[0].Bg != "" {
+		t.Errorf("applyTheme(defaultTheme) = %+v; want the link unchanged", spans)
+	}
+}
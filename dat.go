@@ -37,7 +37,8 @@ const (
 	QWwrsel
 	QWtag
 	QWxdata
-	QWspans // window's spans file
+	QWspans       // window's spans file
+	QWdiagnostics // window's diagnostics file
 	QMAX
 )
 
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity classifies a Diagnostic. The zero value is SeverityError.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+	SeverityHint
+	numSeverities
+)
+
+var severityNames = [numSeverities]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+	SeverityHint:    "hint",
+}
+
+func (s Severity) String() string {
+	if s < 0 || s >= numSeverities {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// parseSeverity returns the Severity with the given name.
+func parseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown diagnostic severity: %q", name)
+}
+
+// Diagnostic is a message attached to a range of a window's body,
+// written by an external tool (a linter, compiler or language server)
+// to the window's diagnostics file.
+type Diagnostic struct {
+	// Start, End are body rune offsets, half-open [Start, End).
+	Start, End int
+	Severity   Severity
+	Message    string
+}
+
+// String formats d in the diagnostics file wire format.
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%d %d %v %s", d.Start, d.End-d.Start, d.Severity, d.Message)
+}
+
+// DiagStore holds a window's diagnostics ordered by Start. Like
+// SpanStore and RegionStore, it is kept in step with body edits so
+// each diagnostic continues to cover the same text after the body
+// changes.
+type DiagStore struct {
+	diags []*Diagnostic
+}
+
+// NewDiagStore creates an empty DiagStore.
+func NewDiagStore() *DiagStore {
+	return &DiagStore{}
+}
+
+// Len returns the number of diagnostics in the store.
+func (s *DiagStore) Len() int {
+	return len(s.diags)
+}
+
+// All returns the diagnostics in Start order. The returned slice is
+// the store's internal storage; callers should not mutate it.
+func (s *DiagStore) All() []*Diagnostic {
+	return s.diags
+}
+
+// Clear removes all diagnostics.
+func (s *DiagStore) Clear() {
+	s.diags = nil
+}
+
+// Add inserts diagnostics into the store, keeping it ordered by Start.
+func (s *DiagStore) Add(diags []*Diagnostic) {
+	s.diags = append(s.diags, diags...)
+	sort.SliceStable(s.diags, func(i, j int) bool {
+		return s.diags[i].Start < s.diags[j].Start
+	})
+}
+
+// At returns the diagnostics whose range overlaps [q0, q1). An empty
+// range selects the diagnostics containing q0. A diagnostic with an
+// empty range is treated as covering the rune at its Start.
+func (s *DiagStore) At(q0, q1 int) []*Diagnostic {
+	if q1 == q0 {
+		q1 = q0 + 1
+	}
+	var found []*Diagnostic
+	for _, d := range s.diags {
+		if d.Start >= q1 {
+			break
+		}
+		if q0 < max(d.End, d.Start+1) {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+// Insert shifts diagnostics to account for length runes inserted at
+// body position pos. Insertion at or before a diagnostic's Start moves
+// it; insertion strictly inside it grows it. This is the same rule as
+// RegionStore.Insert.
+func (s *DiagStore) Insert(pos, length int) {
+	if length <= 0 {
+		return
+	}
+	for _, d := range s.diags {
+		switch {
+		case pos <= d.Start:
+			d.Start += length
+			d.End += length
+		case pos < d.End:
+			d.End += length
+		}
+	}
+}
+
+// Delete adjusts diagnostics for the deletion of length runes at body
+// position pos. Unlike RegionStore.Delete, a diagnostic that overlaps
+// the deleted text is clipped rather than dropped: the remaining text
+// is still what the tool complained about. A diagnostic whose entire
+// non-empty range is deleted is removed.
+func (s *DiagStore) Delete(pos, length int) {
+	if length <= 0 {
+		return
+	}
+	delEnd := pos + length
+	clip := func(q int) int {
+		switch {
+		case q <= pos:
+			return q
+		case q < delEnd:
+			return pos
+		}
+		return q - length
+	}
+	kept := s.diags[:0]
+	for _, d := range s.diags {
+		covered := d.Start < d.End && pos <= d.Start && d.End <= delEnd
+		if covered {
+			continue
+		}
+		d.Start = clip(d.Start)
+		d.End = clip(d.End)
+		kept = append(kept, d)
+	}
+	for i := len(kept); i < len(s.diags); i++ {
+		s.diags[i] = nil
+	}
+	s.diags = kept
+}
+
+// String formats the store in the diagnostics file wire format, one
+// diagnostic per line.
+func (s *DiagStore) String() string {
+	var b strings.Builder
+	for _, d := range s.diags {
+		b.WriteString(d.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// parseDiagnostics parses a write to the diagnostics file. Each
+// non-empty line has the form
+//
+//	offset length severity message...
+//
+// where offset and length are in runes and severity is one of error,
+// warning, info or hint. A write consisting of the single line "c"
+// clears the window's diagnostics. Diagnostics extending past bufLen
+// are clipped; ones starting past it are discarded.
+func parseDiagnostics(data string, bufLen int) (diags []*Diagnostic, isClear bool, err error) {
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "c" {
+			if strings.TrimSpace(data) != "c" {
+				return nil, false, fmt.Errorf("clear must be the only command in a write")
+			}
+			return nil, true, nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, false, fmt.Errorf("bad diagnostic format: need offset length severity [message]")
+		}
+		offset, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, false, fmt.Errorf("bad diagnostic offset: %q", fields[0])
+		}
+		length, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, false, fmt.Errorf("bad diagnostic length: %q", fields[1])
+		}
+		if offset < 0 || length < 0 {
+			return nil, false, fmt.Errorf("negative diagnostic offset or length")
+		}
+		sev, err := parseSeverity(fields[2])
+		if err != nil {
+			return nil, false, err
+		}
+		msg := line
+		for range 3 {
+			msg = strings.TrimLeft(msg, " \t")
+			msg = msg[strings.IndexAny(msg+" ", " \t"):]
+		}
+		msg = strings.TrimSpace(msg)
+		if offset > bufLen {
+			continue
+		}
+		diags = append(diags, &Diagnostic{
+			Start:    offset,
+			End:      min(offset+length, bufLen),
+			Severity: sev,
+			Message:  msg,
+		})
+	}
+	return diags, false, nil
+}
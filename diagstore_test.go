@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	got, isClear, err := parseDiagnostics("0 3 error undefined: x\n\n10 0 hint  consider   this\n95 10 warning near end\n200 1 info gone\n", 100)
+	if err != nil {
+		t.Fatalf("parseDiagnostics failed: %v", err)
+	}
+	if isClear {
+		t.Errorf("parseDiagnostics reported clear")
+	}
+	want := []*Diagnostic{
+		{0, 3, SeverityError, "undefined: x"},
+		{10, 10, SeverityHint, "consider   this"},
+		{95, 100, SeverityWarning, "near end"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseDiagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	_, isClear, err = parseDiagnostics("c\n", 100)
+	if err != nil || !isClear {
+		t.Errorf("parseDiagnostics(\"c\") = %v, %v; want clear", isClear, err)
+	}
+}
+
+func TestParseDiagnosticsErrors(t *testing.T) {
+	for _, data := range []string{
+		"0 3",
+		"x 3 error msg",
+		"0 y error msg",
+		"-1 3 error msg",
+		"0 3 fatal msg",
+		"c\n0 3 error msg",
+	} {
+		if _, _, err := parseDiagnostics(data, 100); err == nil {
+			t.Errorf("parseDiagnostics(%q) succeeded; want error", data)
+		}
+	}
+}
+
+func TestDiagStoreAt(t *testing.T) {
+	s := NewDiagStore()
+	s.Add([]*Diagnostic{
+		{10, 20, SeverityWarning, "b"},
+		{0, 5, SeverityError, "a"},
+		{30, 30, SeverityHint, "c"},
+	})
+	if got := s.String(); got != "0 5 error a\n10 10 warning b\n30 0 hint c\n" {
+		t.Errorf("String() = %q", got)
+	}
+
+	tests := []struct {
+		q0, q1 int
+		want   []string
+	}{
+		{0, 0, []string{"a"}},
+		{4, 4, []string{"a"}},
+		{5, 5, nil},
+		{3, 12, []string{"a", "b"}},
+		{20, 30, nil},
+		{30, 30, []string{"c"}},
+		{29, 31, []string{"c"}},
+	}
+	for _, tc := range tests {
+		var got []string
+		for _, d := range s.At(tc.q0, tc.q1) {
+			got = append(got, d.Message)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("At(%d, %d) mismatch (-want +got):\n%s", tc.q0, tc.q1, diff)
+		}
+	}
+}
+
+func TestDiagStoreEdits(t *testing.T) {
+	s := NewDiagStore()
+	s.Add([]*Diagnostic{
+		{0, 5, SeverityError, "a"},
+		{10, 20, SeverityWarning, "b"},
+		{30, 32, SeverityInfo, "c"},
+	})
+
+	s.Insert(10, 3) // before b: shifts it
+	s.Insert(15, 2) // inside b: grows it
+	if got, want := s.String(), "0 5 error a\n13 12 warning b\n35 2 info c\n"; got != want {
+		t.Errorf("after Insert: got %q, want %q", got, want)
+	}
+
+	s.Delete(3, 12) // clips a and b
+	if got, want := s.String(), "0 3 error a\n3 10 warning b\n23 2 info c\n"; got != want {
+		t.Errorf("after Delete: got %q, want %q", got, want)
+	}
+
+	s.Delete(20, 10) // covers c entirely
+	if got, want := s.String(), "0 3 error a\n3 10 warning b\n"; got != want {
+		t.Errorf("after covering Delete: got %q, want %q", got, want)
+	}
+
+	s.Clear()
+	if s.Len() != 0 {
+		t.Errorf("Len after Clear = %d", s.Len())
+	}
+}
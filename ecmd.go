@@ -5,9 +5,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/rjkroege/edwood/file"
+	"github.com/rjkroege/edwood/regexp"
 	"github.com/rjkroege/edwood/util"
 )
 
@@ -53,7 +55,7 @@ func cmdexec(t *Text, cp *Cmd) bool {
 	}
 
 	if w == nil && (cp.addr == nil || cp.addr.typ != '"') &&
-		!strings.ContainsRune("bBnqUXY!", cp.cmdc) && // Commands that don't need a window
+		!strings.ContainsRune("bBnquUXY!", cp.cmdc) && // Commands that don't need a window
 		!(cp.cmdc == 'D' && len(cp.text) > 0) {
 		editerror("no current window")
 	}
@@ -320,6 +322,15 @@ func i_cmd(t *Text, cp *Cmd) bool {
 	return appendx(t.file, cp, addr.r.q0)
 }
 
+// k_cmd keeps dot as one of the selections the window has when the Edit
+// command finishes.
+func k_cmd(t *Text, cp *Cmd) bool {
+	t.keep = append(t.keep, addr.r)
+	t.q0 = addr.r.q0
+	t.q1 = addr.r.q1
+	return true
+}
+
 func copyx(f *file.ObservableEditableBuffer, addr2 Address) {
 	ni := 0
 	buf := make([]rune, RBUFSIZE)
@@ -367,10 +378,33 @@ func m_cmd(t *Text, cp *Cmd) bool {
 	return true
 }
 
+// n_cmd lists the files in windows, as sam's n lists its menu.
+func n_cmd(t *Text, cp *Cmd) bool {
+	var files []*file.ObservableEditableBuffer
+	global.row.AllWindows(func(w *Window) {
+		if !slices.Contains(files, w.body.file) {
+			files = append(files, w.body.file)
+		}
+	})
+	slices.SortStableFunc(files, func(a, b *file.ObservableEditableBuffer) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	for _, f := range files {
+		pfilename(f)
+	}
+	return true
+}
+
 func p_cmd(t *Text, cp *Cmd) bool {
 	return pdisplay(t.file)
 }
 
+// q_cmd exits like Exit, if no window has unsaved changes.
+func q_cmd(t *Text, cp *Cmd) bool {
+	xexit(nil, nil, nil, false, false, "")
+	return true
+}
+
 func s_cmd(t *Text, cp *Cmd) bool {
 	n := cp.num
 	op := -1
@@ -455,22 +489,41 @@ func s_cmd(t *Text, cp *Cmd) bool {
 	return true
 }
 
+// u_cmd undoes the most recent change, or with a negative count redoes
+// the most recently undone one. As in sam, a change is that of a whole
+// Edit command or other action, in whichever files it was made.
 func u_cmd(t *Text, cp *Cmd) bool {
 	n := cp.num
-	flag := true
+	isundo := true
 	if n < 0 {
 		n = -n
-		flag = false
+		isundo = false
 	}
-	oseq := -1
-	for n > 0 && t.file.Seq() != oseq {
-		n--
-		oseq = t.file.Seq()
-		undo(t, nil, nil, flag, false, "")
+	for ; n > 0; n-- {
+		w := lastchange(isundo)
+		if w == nil {
+			break
+		}
+		undo(&w.body, nil, nil, isundo, false, "")
 	}
 	return true
 }
 
+// lastchange returns a window whose file has the change that Undo, or
+// Redo if isundo is false, acts on next: the latest change to undo or
+// the earliest to redo.
+func lastchange(isundo bool) *Window {
+	var last *Window
+	seq := 0
+	global.row.AllWindows(func(w *Window) {
+		s := seqof(w, isundo)
+		if s > 0 && (last == nil || isundo && s > seq || !isundo && s < seq) {
+			last, seq = w, s
+		}
+	})
+	return last
+}
+
 func w_cmd(t *Text, cp *Cmd) bool {
 	file := t.file
 	if file.Seq() == global.seq {
@@ -910,8 +963,8 @@ func filelooper(t *Text, cp *Cmd, XY bool) {
 
 // TODO(flux) This actually looks like "find one match after p"
 // This is almost certainly broken for ^
-func nextmatch(file *file.ObservableEditableBuffer, r string, p int, sign int) {
-	are, err := rxcompile(r)
+func nextmatch(file *file.ObservableEditableBuffer, r string, flags regexp.Flags, p int, sign int) {
+	are, err := rxcompileflags(r, flags)
 	if err != nil {
 		editerror("bad regexp in command address")
 	}
@@ -992,7 +1045,7 @@ func cmdaddress(ap *Addr, a Address, sign int) Address {
 			} else {
 				qbydir = a.r.q0
 			}
-			nextmatch(file, ap.re, qbydir, sign)
+			nextmatch(file, ap.re, ap.flags, qbydir, sign)
 			a.r = sel[0]
 
 		case '"':
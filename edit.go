@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/rjkroege/edwood/file"
+	"github.com/rjkroege/edwood/regexp"
 )
 
 var (
@@ -32,11 +33,12 @@ func (e badDelimiterError) Error() string {
 }
 
 type Addr struct {
-	typ  rune // # (byte addr), l (line addr), / ? . $ + - , ;
-	re   string
-	left *Addr // left side of , and ;
-	num  int
-	next *Addr // or right side of , and ;
+	typ   rune // # (byte addr), l (line addr), / ? . $ + - , ;
+	re    string
+	flags regexp.Flags // for / and ?
+	left  *Addr        // left side of , and ;
+	num   int
+	next  *Addr // or right side of , and ;
 }
 
 type Address struct {
@@ -100,8 +102,11 @@ var cmdtab = []Cmdtab{
 	{'f', false, false, false, 0, aNo, cNo, wordx, f_cmd},
 	{'g', false, true, false, 'p', aDot, cNo, "", nil}, // Assingned to g_cmd in init() to avoid initialization loop
 	{'i', true, false, false, 0, aDot, cNo, "", i_cmd},
+	{'k', false, false, false, 0, aDot, cNo, "", k_cmd},
 	{'m', false, false, true, 0, aDot, cNo, "", m_cmd},
+	{'n', false, false, false, 0, aNo, cNo, "", n_cmd},
 	{'p', false, false, false, 0, aDot, cNo, "", p_cmd},
+	{'q', false, false, false, 0, aNo, cNo, "", q_cmd},
 	{'r', false, false, false, 0, aDot, cNo, wordx, e_cmd},
 	{'s', false, true, false, 0, aDot, cUnsigned, "", s_cmd},
 	{'t', false, false, true, 0, aDot, cNo, "", m_cmd},
@@ -119,9 +124,6 @@ var cmdtab = []Cmdtab{
 	{'|', false, false, false, 0, aDot, cNo, linex, pipe_cmd},
 	{'>', false, false, false, 0, aDot, cNo, linex, pipe_cmd},
 	/* deliberately unimplemented:
-	{'k', false, false, false, 0, aDot, cNo, "", k_cmd},
-	{'n', false, false, false, 0, aNo, cNo, "", n_cmd},
-	{'q', false, false, false, 0, aNo, cNo, "", q_cmd},
 	{'!', false, false, false, 0, aNo, cNo, linex, plan9_cmd},
 	*/
 }
@@ -168,18 +170,27 @@ func editthread(cp *cmdParser) {
 
 func allelogterm(w *Window) {
 	w.body.file.Elog.Term()
+	w.body.keep = nil
 }
 
 func alleditinit(w *Window) {
 	w.tag.Commit()
 	w.body.Commit()
 	w.body.file.EditClean = false
+	w.body.keep = nil
 }
 
 func allupdate(w *Window) {
 	t := &w.body
 	f := t.file
 
+	// What k kept is in the coordinates of the text before the edits.
+	keep := t.keep
+	t.keep = nil
+	for i, r := range keep {
+		keep[i].q0, keep[i].q1 = f.Elog.Map(r.q0, r.q1)
+	}
+
 	if !f.Elog.Empty() {
 		owner := t.w.owner
 		if owner == 0 {
@@ -196,6 +207,9 @@ func allupdate(w *Window) {
 		}
 		t.w.owner = owner
 	}
+	if n := len(keep); n > 0 {
+		t.setSelections(keep[n-1].q0, keep[n-1].q1, keep[:n-1])
+	}
 }
 
 func editerror(format string, args ...interface{}) {
@@ -205,14 +219,16 @@ func editerror(format string, args ...interface{}) {
 	runtime.Goexit()
 }
 
-func editcmd(ct *Text, r []rune) {
+// editcmd runs the Edit command r with ct as the current text. It
+// returns the names of the files that the command changed.
+func editcmd(ct *Text, r []rune) []string {
 	if len(r) == 0 {
-		return
+		return nil
 	}
 
 	if len(r) > 2*RBUFSIZE {
 		warning(nil, "string too long\n")
-		return
+		return nil
 	}
 
 	global.row.AllWindows(alleditinit)
@@ -235,8 +251,17 @@ func editcmd(ct *Text, r []rune) {
 	if err != nil {
 		warning(nil, "Edit: %s\n", err)
 	}
+	var changed []string
+	seen := make(map[*file.ObservableEditableBuffer]bool)
+	global.row.AllWindows(func(w *Window) {
+		if f := w.body.file; !f.Elog.Empty() && !seen[f] {
+			seen[f] = true
+			changed = append(changed, f.Name())
+		}
+	})
 	// update everyone whose edit log has data
 	global.row.AllWindows(allupdate)
+	return changed
 }
 
 func newCmdParser(r []rune) *cmdParser {
@@ -613,6 +638,35 @@ func (cp *cmdParser) getregexp(delim rune) (string, error) {
 	return lastpat, nil
 }
 
+// getreflags reads the letters that may follow the regular expression
+// of a / or ? address: i to match without regard to case and w to match
+// whole words. If the last letter is followed by a blank or a slash, it
+// is a command instead, as in /re/i/text/ or /re/w file.
+func (cp *cmdParser) getreflags() regexp.Flags {
+	var letters []rune
+	for {
+		c := cp.nextc()
+		if c != 'i' && c != 'w' {
+			if n := len(letters); n > 0 && (c == ' ' || c == '\t' || c == '/') {
+				cp.ungetch()
+				letters = letters[:n-1]
+			}
+			break
+		}
+		letters = append(letters, cp.getch())
+	}
+	var flags regexp.Flags
+	for _, c := range letters {
+		switch c {
+		case 'i':
+			flags |= regexp.FoldCase
+		case 'w':
+			flags |= regexp.WholeWord
+		}
+	}
+	return flags
+}
+
 func (cp *cmdParser) simpleaddr() (*Addr, error) {
 	var addr Addr
 
@@ -630,6 +684,9 @@ func (cp *cmdParser) simpleaddr() (*Addr, error) {
 		if err != nil {
 			return nil, err
 		}
+		if addr.typ != '"' {
+			addr.flags = cp.getreflags()
+		}
 	case '.', '$', '+', '-', '\'':
 		addr.typ = cp.getch()
 	default:
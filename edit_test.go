@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/rjkroege/edwood/dumpfile"
+	"github.com/rjkroege/edwood/regexp"
 )
 
 type teststimulus struct {
@@ -96,6 +97,11 @@ func TestEdit(t *testing.T) {
 		// { } NB: grouping requires newlines. And sets . the same for each of the commands.
 		{Range{0, 0}, "test", ",x {\n i/@/ \n a/%/\n }", "@This is a%\n@short text%\n@to try addressing%\n", []string{}},
 		// TODO(rjk): { has a number of constraints not being exercised in this test.
+
+		// address flags
+		{Range{0, 0}, "test", "/SHORT/id", "This is a\n text\nto try addressing\n", []string{}},
+		{Range{0, 0}, "test", "/is/wd", "This  a\nshort text\nto try addressing\n", []string{}},
+		{Range{9, 9}, "test", "?IS?iwd", "This  a\nshort text\nto try addressing\n", []string{}},
 	}
 
 	buf := make([]rune, 8192)
@@ -557,6 +563,11 @@ func TestSimpleaddr(t *testing.T) {
 		{[]rune(`?a\?bc?` + "\n"), &Addr{typ: '?', re: "a?bc"}, nil},
 		{[]rune(`?a\nbc?` + "\n"), &Addr{typ: '?', re: `a\nbc`}, nil},
 		{[]rune(`?a\\bc?` + "\n"), &Addr{typ: '?', re: `a\\bc`}, nil},
+		{[]rune("/abc/i\n"), &Addr{typ: '/', re: "abc", flags: regexp.FoldCase}, nil},
+		{[]rune("?abc?iw\n"), &Addr{typ: '?', re: "abc", flags: regexp.FoldCase | regexp.WholeWord}, nil},
+		{[]rune("/abc/wi/def/\n"), &Addr{typ: '/', re: "abc", flags: regexp.WholeWord}, nil},
+		{[]rune("/abc/w file\n"), &Addr{typ: '/', re: "abc"}, nil},
+		{[]rune("/abc/i+\n"), &Addr{typ: '/', re: "abc", flags: regexp.FoldCase, next: &Addr{typ: '+'}}, nil},
 		{[]rune(`"abc` + "\n"), &Addr{typ: '"', re: "abc"}, nil},
 		{[]rune(`"abc"` + "\n"), &Addr{typ: '"', re: "abc"}, nil},
 		{[]rune(".\n"), &Addr{typ: '.'}, nil},
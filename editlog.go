@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+)
+
+// Edit history.
+//
+// Each Edit command run in a session is added to the end of the +Edit
+// window, with the files it changed and the window it ran in:
+//
+//	Edit ,s/Foo/Bar/g
+//		# changed /home/gopher/a.go /home/gopher/b.go
+//		# in /home/gopher/a.go
+//
+// The further lines of a command of several lines are indented by a tab.
+// An entry runs from a line that starts with "Edit " to the next line
+// that does not start with a tab. Clicking B2 in an entry runs its
+// command again, in the window named by its "in" line rather than in
+// the +Edit window. An entry without one runs with no current window,
+// as from the row's tag.
+
+const plusEdit = "+Edit"
+
+// runEdit runs the Edit command cmd from ct and logs it in +Edit.
+func runEdit(ct *Text, cmd string) {
+	global.seq++
+	changed := editcmd(ct, []rune(cmd))
+	in := ""
+	if ct.w != nil {
+		in = ct.w.body.file.Name()
+	}
+	logEdit(ct, formatEditLog(cmd, in, changed))
+}
+
+// logEdit adds entry to the end of the +Edit window, making the window
+// next to t if there is none.
+func logEdit(t *Text, entry string) {
+	if entry == "" {
+		return
+	}
+	w := lookfile(plusEdit)
+	if w == nil {
+		w = makenewwindow(t)
+		w.SetName(plusEdit)
+		w.filemenu = false
+		xfidlog(w, "new")
+	}
+	lt := &w.body
+	q := lt.Nc()
+	lt.Insert(q, []rune(entry), true)
+	lt.file.Clean()
+	lt.Show(q, q, true)
+	lt.ScrDraw()
+}
+
+// formatEditLog returns the +Edit entry for cmd, run in the window of
+// the file named in, which changed the files named changed.
+func formatEditLog(cmd, in string, changed []string) string {
+	cmd = strings.TrimRight(cmd, "\n")
+	if strings.TrimSpace(cmd) == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Edit ")
+	b.WriteString(strings.ReplaceAll(cmd, "\n", "\n\t"))
+	b.WriteString("\n\t# changed")
+	for _, name := range changed {
+		if name != "" {
+			b.WriteString(" ")
+			b.WriteString(QuoteFilename(name))
+		}
+	}
+	b.WriteString("\n")
+	if in != "" {
+		b.WriteString("\t# in ")
+		b.WriteString(QuoteFilename(in))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// parseEditLog returns the command of the +Edit entry in r that contains
+// rune offset q, and the file named by its "in" line. ok is false if q
+// is not in an entry.
+func parseEditLog(r []rune, q int) (cmd, in string, ok bool) {
+	line := func(q int) (int, int) {
+		q0, q1 := q, q
+		for q0 > 0 && r[q0-1] != '\n' {
+			q0--
+		}
+		for q1 < len(r) && r[q1] != '\n' {
+			q1++
+		}
+		return q0, q1
+	}
+
+	q0, q1 := line(q)
+	for !strings.HasPrefix(string(r[q0:q1]), "Edit ") {
+		if q0 == 0 || q0 == q1 || r[q0] != '\t' {
+			return "", "", false
+		}
+		q0, q1 = line(q0 - 1)
+	}
+
+	lines := []string{string(r[q0+len("Edit ") : q1])}
+	for q1 < len(r) {
+		q0, q1 = line(q1 + 1)
+		s, ok := strings.CutPrefix(string(r[q0:q1]), "\t")
+		if !ok {
+			break
+		}
+		switch {
+		case strings.HasPrefix(s, "# in "):
+			in = UnquoteFilename(strings.TrimPrefix(s, "# in "))
+		case strings.HasPrefix(s, "# changed"):
+		default:
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n"), in, true
+}
+
+// rerunEditLog runs the Edit command of the +Edit entry clicked at q,
+// and reports whether there was one. t must be the text clicked.
+func rerunEditLog(t *Text, q int) bool {
+	if t.w == nil || t != &t.w.body || t.file.Name() != plusEdit {
+		return false
+	}
+	cmd, in, ok := parseEditLog([]rune(t.file.String()), q)
+	if !ok {
+		return false
+	}
+	ct := &global.row.tag
+	if in != "" {
+		w := lookfile(in)
+		if w == nil {
+			warning(nil, "Edit: no window for %s\n", in)
+			return true
+		}
+		// Edit expects the window it runs in to be locked, as t.w is.
+		if w != t.w {
+			w.Lock('M')
+			defer w.Unlock()
+		}
+		ct = &w.body
+	}
+	runEdit(ct, cmd)
+	return true
+}
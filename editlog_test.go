@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEditLog(t *testing.T) {
+	log := formatEditLog(",s/a/b/g\n", "/home/gopher/a b.txt", []string{"/home/gopher/a b.txt", "/home/gopher/c.txt"}) +
+		"\n" +
+		formatEditLog("1a\nline\n.", "", nil)
+	want := "Edit ,s/a/b/g\n" +
+		"\t# changed '/home/gopher/a b.txt' /home/gopher/c.txt\n" +
+		"\t# in '/home/gopher/a b.txt'\n" +
+		"\n" +
+		"Edit 1a\n" +
+		"\tline\n" +
+		"\t.\n" +
+		"\t# changed\n"
+	if log != want {
+		t.Fatalf("formatEditLog gave\n%s\nwant\n%s", log, want)
+	}
+
+	r := []rune(log)
+	at := func(s string) int { return len([]rune(log[:strings.Index(log, s)])) }
+	for _, tc := range []struct {
+		q       int
+		cmd, in string
+		ok      bool
+	}{
+		{0, ",s/a/b/g", "/home/gopher/a b.txt", true},
+		{at("# in"), ",s/a/b/g", "/home/gopher/a b.txt", true},
+		{at("\n\nEdit") + 1, "", "", false},
+		{at("line"), "1a\nline\n.", "", true},
+		{len(r), "", "", false},
+	} {
+		cmd, in, ok := parseEditLog(r, tc.q)
+		if cmd != tc.cmd || in != tc.in || ok != tc.ok {
+			t.Errorf("parseEditLog at %d gave %q, %q, %v; want %q, %q, %v", tc.q, cmd, in, ok, tc.cmd, tc.in, tc.ok)
+		}
+	}
+}
+
+func TestEditLog(t *testing.T) {
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin("/home/gopher/a.txt"),
+		ScBody("/home/gopher/a.txt", "one two"),
+		ScWin("/home/gopher/b.txt"),
+		ScBody("/home/gopher/b.txt", "two three"),
+	)
+	a := global.row.col[0].w[0]
+	b := global.row.col[0].w[1]
+	// +Edit is made in the active column.
+	global.activecol = a.col
+	bodies := func() string { return a.body.file.String() + "|" + b.body.file.String() }
+
+	runEdit(&a.body, ",s/one/1/")
+	// X expects the window running it to be locked.
+	b.Lock('M')
+	runEdit(&b.body, "X ,s/two/2/")
+	b.Unlock()
+	if got, want := bodies(), "1 2|2 three"; got != want {
+		t.Fatalf("after editing, bodies are %q; want %q", got, want)
+	}
+
+	lw := lookfile(plusEdit)
+	if lw == nil {
+		t.Fatal("no +Edit window")
+	}
+	want := "Edit ,s/one/1/\n\t# changed /home/gopher/a.txt\n\t# in /home/gopher/a.txt\n" +
+		"Edit X ,s/two/2/\n\t# changed /home/gopher/a.txt /home/gopher/b.txt\n\t# in /home/gopher/b.txt\n"
+	if got := lw.body.file.String(); got != want {
+		t.Errorf("+Edit is\n%s\nwant\n%s", got, want)
+	}
+
+	// u undoes the whole of the last Edit command, in both files, and
+	// then the one before it, though b.txt played no part in it.
+	runEdit(&b.body, "u")
+	if got, want := bodies(), "1 two|two three"; got != want {
+		t.Errorf("after u, bodies are %q; want %q", got, want)
+	}
+	runEdit(&b.body, "u")
+	if got, want := bodies(), "one two|two three"; got != want {
+		t.Errorf("after u u, bodies are %q; want %q", got, want)
+	}
+	runEdit(&b.body, "u-2")
+	if got, want := bodies(), "1 2|2 three"; got != want {
+		t.Errorf("after u-2, bodies are %q; want %q", got, want)
+	}
+
+	// Clicking the first entry runs it again in a.txt.
+	a.body.file.InsertAt(0, []rune("one "))
+	if !rerunEditLog(&lw.body, 2) {
+		t.Fatal("click in +Edit entry ran nothing")
+	}
+	if got, want := bodies(), "1 1 2|2 three"; got != want {
+		t.Errorf("after rerunning, bodies are %q; want %q", got, want)
+	}
+	if rerunEditLog(&a.body, 0) {
+		t.Error("click outside +Edit ran an entry")
+	}
+}
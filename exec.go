@@ -64,8 +64,11 @@ var globalexectab = []Exectab{
 	{"Dump", dump, false, true, true /*unused*/},
 	{"Edit", edit, false, true /*unused*/, true /*unused*/},
 	{"Exit", xexit, false, true /*unused*/, true /*unused*/},
+	{"Follow", follow, false, true /*unused*/, true /*unused*/},
 	{"Font", fontx, false, true /*unused*/, true /*unused*/},
 	{"Get", get, false, true, true /*unused*/},
+	{"History", history, false, true /*unused*/, true /*unused*/},
+	{"Hooks", hookscmd, false, true /*unused*/, true /*unused*/},
 	{"ID", id, false, true /*unused*/, true /*unused*/},
 	//	{ "Incl",		incl,		false,	true /*unused*/,		true /*unused*/		},
 	{"Indent", indent, false, true /*unused*/, true /*unused*/},
@@ -255,6 +258,11 @@ func execute(t *Text, aq0 int, aq1 int, external bool, argt *Text) {
 		return
 	}
 
+	// A click in the +Edit window runs the Edit command under it.
+	if aq0 == aq1 && rerunEditLog(t, aq0) {
+		return
+	}
+
 	// Invoke an internal command if it exists.
 	if e != nil {
 		if (e.mark && global.seltext != nil) && global.seltext.what == Body {
@@ -328,13 +336,10 @@ func edit(et *Text, _ *Text, argt *Text, _, _ bool, arg string) {
 		return
 	}
 	r, _ := getarg(argt, false, true)
-
-	global.seq++
-	if r != "" {
-		editcmd(et, []rune(r))
-	} else {
-		editcmd(et, []rune(arg))
+	if r == "" {
+		r = arg
 	}
+	runEdit(et, r)
 }
 
 func xexit(*Text, *Text, *Text, bool, bool, string) {
@@ -530,9 +535,11 @@ func get(et *Text, _ *Text, argt *Text, flag1 bool, _ bool, arg string) {
 		return
 	}
 
-	t.Delete(0, t.file.Nr(), true)
 	samename := name == t.file.Name()
-	t.Load(0, name, samename)
+	if !samename || !t.loadMore(name) {
+		t.Delete(0, t.file.Nr(), true)
+		t.Load(0, name, samename)
+	}
 
 	// Text.Delete followed by Text.Load will always mark the File as
 	// modified unless loading a 0-length file over a 0-length file. But if
@@ -595,7 +602,18 @@ func putfile(oeb *file.ObservableEditableBuffer, q0 int, q1 int, name string) er
 		}
 	}
 
-	fd, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0666)
+	// A large file is read as it is written, so write a new file and
+	// rename it over the one being read.
+	renamed := err == nil && oeb.PagesIn(d)
+	var fd *os.File
+	if renamed {
+		fd, err = os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".")
+		if err == nil {
+			err = fd.Chmod(d.Mode().Perm())
+		}
+	} else {
+		fd, err = os.OpenFile(name, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0666)
+	}
 	if err != nil {
 		return warnError(nil, "can't create file %s: %v", name, err)
 	}
@@ -610,7 +628,13 @@ func putfile(oeb *file.ObservableEditableBuffer, q0 int, q1 int, name string) er
 	}
 
 	_, err = io.Copy(io.MultiWriter(h, fd), oeb.Reader(q0, q1))
+	if err == nil && renamed {
+		err = os.Rename(fd.Name(), name)
+	}
 	if err != nil {
+		if renamed {
+			os.Remove(fd.Name())
+		}
 		return warnError(nil, "can't write file %s: %v", name, err)
 	}
 
@@ -630,6 +654,7 @@ func putfile(oeb *file.ObservableEditableBuffer, q0 int, q1 int, name string) er
 			oeb.SetInfo(d)
 			oeb.Set(h.Sum(nil))
 			oeb.Clean()
+			saveUndoJournal(oeb)
 		}
 	}
 	return nil
@@ -688,10 +713,14 @@ func seqof(w *Window, isundo bool) int {
 }
 
 // TODO(rjk): Test the logic of Undo across multiple buffers very carefully: #383
-func undo(et *Text, _ *Text, _ *Text, flag1, _ bool, _ string) {
+func undo(et *Text, _ *Text, _ *Text, flag1, _ bool, arg string) {
 	if et == nil || et.w == nil {
 		return
 	}
+	if flag1 && arg != "" {
+		undoto(et, arg)
+		return
+	}
 	seq := seqof(et.w, flag1)
 	if seq == 0 {
 		// nothing to undo
@@ -779,8 +808,9 @@ func sendx(et *Text, _ *Text, argt *Text, _, _ bool, _ string) {
 func look(et *Text, _ *Text, argt *Text, _, _ bool, arg string) {
 	if et != nil && et.w != nil {
 		t := &et.w.body
+		flags, arg := lookflags(arg)
 		if len(arg) > 0 {
-			search(t, []rune(arg))
+			searchflags(t, []rune(arg), flags)
 			return
 		}
 		r, _ := getarg(argt, false, false)
@@ -790,7 +820,7 @@ func look(et *Text, _ *Text, argt *Text, _, _ bool, arg string) {
 			t.file.Read(t.q0, rb[:n])
 			r = string(rb) // TODO(flux) Too many gross []rune-string conversions in here
 		}
-		search(t, []rune(r))
+		searchflags(t, []rune(r), flags)
 	}
 }
 
@@ -1477,47 +1507,3 @@ func plaincmd(et *Text, _ *Text, _ *Text, _, _ bool, _ string) {
 	w.initStyledMode()
 	w.renderStyledFromBody()
 }
-
-// fileHooks maps lowercase file extensions to external tool names
-// that should be automatically run when a file with that extension is opened.
-var fileHooks = map[string]string{
-	".go":  "edcolor",
-	".py":  "edcolor",
-	".rs":  "edcolor",
-	".tex": "edcolor",
-	".sty": "edcolor",
-	".cls": "edcolor",
-	".md":  "md2spans",
-}
-
-// fileHookTool returns the tool name for the given filename based on its
-// extension, or "" if no hook is configured for that extension.
-func fileHookTool(name string) string {
-	if name == "" {
-		return ""
-	}
-	ext := strings.ToLower(filepath.Ext(name))
-	tool, ok := fileHooks[ext]
-	if !ok {
-		return ""
-	}
-	return tool
-}
-
-// maybeRunFileHook checks if the window's file has an extension that matches
-// a configured file hook, and if so, runs the corresponding tool.
-func maybeRunFileHook(w *Window) {
-	if w == nil {
-		return
-	}
-	toolName := fileHookTool(w.body.file.Name())
-	if toolName == "" {
-		return
-	}
-	if _, err := exec.LookPath(toolName); err != nil {
-		return
-	}
-	dir := w.body.DirName("")
-	w.ref.Inc()
-	run(w, toolName, dir, true, "", "", false)
-}
@@ -169,6 +169,51 @@ func TestPutfile(t *testing.T) {
 	}
 }
 
+func TestPutfileLarge(t *testing.T) {
+	old := file.LargeFileSize
+	file.LargeFileSize = 1
+	defer func() { file.LargeFileSize = old }()
+
+	filename := filepath.Join(t.TempDir(), "large.txt")
+	text := strings.Repeat("Hello, 世界\n", 20000)
+	if err := os.WriteFile(filename, []byte(text), 0640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	d, _ := fd.Stat()
+	oeb := file.MakeObservableEditableBuffer(filename, nil)
+	oeb.SetInfo(d)
+	oeb.Load(0, fd, true)
+	fd.Close()
+	oeb.Clean()
+	if !oeb.IsLarge() {
+		t.Fatal("file is not paged in")
+	}
+
+	// Writing over the file the text is paged in from must not change
+	// the text written.
+	oeb.InsertAt(0, []rune("first\n"))
+	if err := putfile(oeb, 0, oeb.Nr(), filename); err != nil {
+		t.Fatalf("putfile failed: %v", err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(b) != "first\n"+text {
+		t.Errorf("file content differs from the buffer")
+	}
+	if d, err := os.Stat(filename); err != nil || d.Mode().Perm() != 0640 {
+		t.Errorf("file mode is %v, %v; want 0640", d.Mode(), err)
+	}
+	if oeb.String() != "first\n"+text {
+		t.Errorf("buffer changed by writing the file")
+	}
+}
+
 // TODO(rjk): Add A case here for partial writes.
 
 func TestExpandtabToggle(t *testing.T) {
@@ -806,7 +851,10 @@ func TestFileHookTool(t *testing.T) {
 		{"/path/to/file.py", "edcolor"},
 		{"/path/to/file.rs", "edcolor"},
 		{"/path/to/file.tex", "edcolor"},
-		{"/path/to/file.c", ""},
+		{"/path/to/file.c", "edcolor"},
+		{"/path/to/file.txt", ""},
+		{"/path/to/Makefile", "edcolor"},
+		{"config.yml", "edcolor"},
 		{"noext", ""},
 	}
 	for _, tc := range tests {
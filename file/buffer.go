@@ -79,6 +79,16 @@
 // and deletions). An action is represented by any operations between two calls of
 // Commit method. Anything that happens between these two calls is a part of that
 // particular action.
+//
+// # History
+//
+// The actions form a tree. Each action was made in the state left by its
+// parent, and the root stands for the state before any action. Undo
+// moves to the parent of the current action. An action made after an
+// Undo starts a new branch rather than discarding the undone actions.
+// Redo follows the branch most recently left by Undo or made, and
+// UndoTo reaches any state by undoing to a common ancestor and redoing
+// from there.
 package file
 
 // TODO(rjk): Considerations of the efficiency of file.Buffer must make
@@ -88,12 +98,15 @@ package file
 // roughly equal.
 
 import (
+	"container/list"
 	"errors"
 	"io"
 	"log"
+	"time"
 	"unicode/utf8"
 
 	"github.com/rjkroege/edwood/sam"
+	"github.com/rjkroege/edwood/util"
 )
 
 var _ io.ReaderAt = (*Buffer)(nil)
@@ -112,8 +125,9 @@ type Buffer struct {
 	begin, end  *piece // sentinel nodes which always exists but don't hold any data
 	cachedPiece *piece // most recently modified piece
 
-	actions       []*action // stack holding all actions performed to the file
-	head          int       // index for the next action to add
+	actions       []*action // every action performed on the file, oldest first
+	root          *action   // sentinel for the state before any action
+	head          *action   // action that made the current state, or root
 	currentAction *action   // action for the current change group
 	savedAction   *action
 
@@ -123,14 +137,16 @@ type Buffer struct {
 	vws    OffsetTuple // OffsetTuple for start of viewed
 	vwl    OffsetTuple // Last determined OffsetTuple
 	pend   OffsetTuple // Cached end of the buffer.
+
+	paged *pagedFile // large file that pieces page in from, if any
 }
 
 // NewBuffer initializes a new buffer with the given content as a starting point.
 // To start with an empty buffer pass nil as a content.
 // TODO(rjk): Should we chunk very large content arrays?
 func NewBuffer(content []byte, nr int) *Buffer {
-	// give the actions stack some default capacity
-	t := &Buffer{actions: make([]*action, 0, 100)}
+	t := &Buffer{}
+	t.FlattenHistory()
 
 	t.begin = t.newEmptyPiece()
 	t.end = t.newPiece(nil, t.begin, nil, 0)
@@ -145,13 +161,20 @@ func NewBuffer(content []byte, nr int) *Buffer {
 	return t
 }
 
+// FlattenHistory discards the undo history, making the current state
+// the root.
 func (b *Buffer) FlattenHistory() {
+	// give the actions some default capacity
 	b.actions = make([]*action, 0, 100)
-	b.head = 0
+	b.root = &action{time: now()}
+	b.head = b.root
 	b.currentAction = nil
-	b.savedAction = nil
+	b.savedAction = b.root
 }
 
+// now is time.Now, replaced in tests.
+var now = time.Now
+
 // Insert inserts the data at the given offset in the buffer. An error is return when the
 // given offset is invalid.
 func (b *Buffer) Insert(start OffsetTuple, data []byte, nr, seq int) error {
@@ -179,6 +202,7 @@ func (b *Buffer) Insert(start OffsetTuple, data []byte, nr, seq int) error {
 	} else if p == b.cachedPiece {
 		// just update the last inserted piece
 		p.insert(offset, data, nr)
+		b.logEdit(off, nil, data)
 		b.validateInvariant()
 		return nil
 	}
@@ -201,9 +225,9 @@ func (b *Buffer) Insert(start OffsetTuple, data []byte, nr, seq int) error {
 		// piece. That is we have 3 new pieces one containing the content
 		// before the insertion point then one holding the newly inserted
 		// text and one holding the content after the insertion point.
-		before := b.newPiece(p.data[:offset], p.prev, nil, roffset)
+		before := b.slicePiece(p, 0, offset, p.prev, nil, roffset)
 		pnew = b.newPiece(data, before, nil, nr)
-		after := b.newPiece(p.data[offset:], pnew, p.next, p.nr-roffset)
+		after := b.slicePiece(p, offset, p.len(), pnew, p.next, p.nr-roffset)
 		before.next = pnew
 		pnew.next = after
 		c.new = newSpan(before, after)
@@ -212,6 +236,7 @@ func (b *Buffer) Insert(start OffsetTuple, data []byte, nr, seq int) error {
 
 	b.cachedPiece = pnew
 	swapSpans(c.old, c.new)
+	b.logEdit(off, nil, data)
 	b.validateInvariant()
 	//log.Println("after", b.viewedState())
 	return nil
@@ -231,6 +256,13 @@ func (b *Buffer) Delete(startOff, endOff OffsetTuple, seq int) error {
 		return nil
 	}
 
+	// Keep the deleted text for the undo journal.
+	var del []byte
+	if b.paged == nil {
+		del = make([]byte, util.Min(length, b.Size()-off))
+		b.ReadAt(del, int64(off))
+	}
+
 	b.pend = b.pend.Sub(length, rlength)
 	p, offset, roffset := b.findPiece(startOff)
 	if p == nil {
@@ -238,6 +270,7 @@ func (b *Buffer) Delete(startOff, endOff OffsetTuple, seq int) error {
 		return ErrWrongOffset
 	} else if p == b.cachedPiece && p.delete(offset, length, int(endOff.R-startOff.R)) {
 		// try to update the last inserted piece if the length doesn't exceed
+		b.logEdit(off, del, nil)
 		b.validateInvariant()
 		return nil
 	}
@@ -286,16 +319,16 @@ func (b *Buffer) Delete(startOff, endOff OffsetTuple, seq int) error {
 		end = p
 
 		beg := p.len() + length - cur
-		newBuf := make([]byte, len(p.data[beg:]))
-		copy(newBuf, p.data[beg:])
+		newBuf := make([]byte, p.len()-beg)
+		copy(newBuf, p.bytes()[beg:])
 		after = b.newPiece(newBuf, before, p.next, rcur-rlength)
 	}
 
 	var newStart, newEnd *piece
 	if midwayStart {
 		// we finally know which piece follows our newly allocated before piece
-		newBuf := make([]byte, len(start.data[:offset]))
-		copy(newBuf, start.data[:offset])
+		newBuf := make([]byte, offset)
+		copy(newBuf, start.bytes()[:offset])
 		before.data = newBuf
 		before.prev, before.next = start.prev, after
 		before.nr = utf8.RuneCount(newBuf)
@@ -317,19 +350,30 @@ func (b *Buffer) Delete(startOff, endOff OffsetTuple, seq int) error {
 	c.new = newSpan(newStart, newEnd)
 	c.old = newSpan(start, end)
 	swapSpans(c.old, c.new)
+	b.logEdit(off, del, nil)
 
 	b.validateInvariant()
 	return nil
 }
 
-// newAction creates a new action and throws away all undone actions.
+// newAction creates a new action, a child of the current one.
 func (b *Buffer) newAction(seq int) *action {
 	a := &action{seq: seq}
-	b.actions = append(b.actions[:b.head], a)
-	b.head++
+	b.addAction(a)
 	return a
 }
 
+// addAction makes a a child of the current action and the new current
+// action. Redo will follow it.
+func (b *Buffer) addAction(a *action) {
+	a.id = len(b.actions) + 1
+	a.parent = b.head
+	a.time = now()
+	b.head.redo = a
+	b.actions = append(b.actions, a)
+	b.head = a
+}
+
 // newChange is associated with the current action or a newly allocated one if
 // none exists.
 func (b *Buffer) newChange(off, roff, seq int) *change {
@@ -347,6 +391,30 @@ func (b *Buffer) newChange(off, roff, seq int) *change {
 	return c
 }
 
+// logEdit records in the current action that the bytes del at off were
+// replaced with ins. Typing and erasing what was just typed extend the
+// previous edit rather than adding one per character.
+func (b *Buffer) logEdit(off int, del, ins []byte) {
+	a := b.currentAction
+	if a == nil || b.paged != nil {
+		return
+	}
+	if n := len(a.edits); n > 0 {
+		last := &a.edits[n-1]
+		end := last.off + len(last.ins)
+		switch {
+		case len(del) == 0 && off == end:
+			last.ins = append(last.ins, ins...)
+			return
+		case len(ins) == 0 && off >= last.off && off+len(del) == end:
+			last.ins = last.ins[:off-last.off]
+			return
+		}
+	}
+	// ins may be the data of a piece, which later edits can change.
+	a.edits = append(a.edits, edit{off, del, append([]byte(nil), ins...)})
+}
+
 // newPiece creates a new piece structure. nr is the number of runes in
 // data.
 func (b *Buffer) newPiece(data []byte, prev, next *piece, nr int) *piece {
@@ -370,6 +438,17 @@ func (b *Buffer) newEmptyPiece() *piece {
 	return b.newPiece(nil, nil, nil, 0)
 }
 
+// slicePiece creates a new piece for the bytes [from, to) of p, which
+// hold nr runes. A piece of a large file stays one.
+func (b *Buffer) slicePiece(p *piece, from, to int, prev, next *piece, nr int) *piece {
+	if p.file == nil {
+		return b.newPiece(p.data[from:to], prev, next, nr)
+	}
+	q := b.newPagedPiece(p.file, p.off+int64(from), to-from, nr)
+	q.prev, q.next = prev, next
+	return q
+}
+
 // findPiece returns the piece holding the text at the byte offset, the
 // byte offset into piece and similarly for the rune offset. If off
 // happens to be at a piece boundary (i.e. the first byte of a piece)
@@ -420,10 +499,12 @@ func (b *Buffer) Undo(_ int) (int, int, bool, int) {
 	// defer log.Println("Undo end")
 	b.validateInvariant()
 	b.SetUndoPoint()
-	a := b.unshiftAction()
-	if a == nil {
+	a := b.head
+	if a == b.root {
 		return -1, 0, false, 0
 	}
+	b.head = a.parent
+	b.head.redo = a
 
 	// TODO(rjk): This is wrong if a filename change and edits are part of
 	// the same action?
@@ -448,12 +529,9 @@ func (b *Buffer) Undo(_ int) (int, int, bool, int) {
 		nr = b.undone(c, true)
 	}
 
-	if b.head == 0 {
-		return roff, roff - nr, true, 0
-	}
 	b.validateInvariant()
 	// TODO(rjk): Conceivably, I need better tests for the return values.
-	return roff, roff - nr, true, b.actions[b.head-1].seq
+	return roff, roff - nr, true, b.head.seq
 }
 
 // undone is an Undo helper to implement Edwood specific callback
@@ -507,20 +585,9 @@ func (b *Buffer) undone(c *change, undo bool) int {
 	return rsize
 }
 
-func (b *Buffer) unshiftAction() *action {
-	if b.head == 0 {
-		return nil
-	}
-	b.head--
-	return b.actions[b.head]
-}
-
 func (b *Buffer) filenameChangeAction(a *action) (int, int, bool, int) {
 	b.oeb.setfilename(a.fname)
-	if b.head > 0 {
-		return -1, 0, false, b.actions[b.head-1].seq
-	}
-	return -1, 0, false, 0
+	return -1, 0, false, b.head.seq
 }
 
 // Redo repeats the last undone action. It returns new selection q0, q1
@@ -531,10 +598,11 @@ func (b *Buffer) Redo(_ int) (int, int, bool, int) {
 	//	defer log.Println("Redo end")
 	b.validateInvariant()
 	b.SetUndoPoint()
-	a := b.shiftAction()
+	a := b.head.redo
 	if a == nil {
 		return -1, 0, false, 0
 	}
+	b.head = a
 
 	if a.kind == sam.Filename {
 		return b.filenameChangeAction(a)
@@ -554,25 +622,78 @@ func (b *Buffer) Redo(_ int) (int, int, bool, int) {
 
 	//	log.Println("redo", roff, roff+nr, true, len(a.changes))
 	b.validateInvariant()
-	return roff, roff - nr, true, b.actions[b.head-1].seq
+	return roff, roff - nr, true, a.seq
 }
 
 // RedoSeq finds the seq of the last redo record.The value of seq is used
 // to track intra and inter File edit actions so that cross-File changes
 // via Edit X can be undone with a single action.
 func (b *Buffer) RedoSeq() int {
-	if len(b.actions) > 0 && b.head < len(b.actions) {
-		return b.actions[b.head].seq
+	if a := b.head.redo; a != nil {
+		return a.seq
 	}
 	return 0
 }
 
-func (b *Buffer) shiftAction() *action {
-	if b.head > len(b.actions)-1 {
-		return nil
+// UndoTo undoes and redoes actions to reach the state made by the action
+// with id, or the root if id is 0. Its results are those of the last
+// Undo or Redo, or -1 as the offset if id is not an action or is
+// already the current state.
+func (b *Buffer) UndoTo(id int) (int, int, bool, int) {
+	target := b.root
+	if id < 0 || id > len(b.actions) {
+		return -1, 0, false, b.head.seq
+	} else if id > 0 {
+		target = b.actions[id-1]
 	}
-	b.head++
-	return b.actions[b.head-1]
+
+	path := make(map[*action]bool)
+	for a := target; a != nil; a = a.parent {
+		path[a] = true
+	}
+	q0, q1, ok, seq := -1, 0, false, b.head.seq
+	for !path[b.head] {
+		q0, q1, ok, seq = b.Undo(0)
+	}
+	var down []*action
+	for a := target; a != b.head; a = a.parent {
+		down = append(down, a)
+	}
+	for i := len(down) - 1; i >= 0; i-- {
+		b.head.redo = down[i]
+		q0, q1, ok, seq = b.Redo(0)
+	}
+	return q0, q1, ok, seq
+}
+
+// A State is a point in the undo history: the state made by an action.
+type State struct {
+	ID      int       // 0 for the root, the state before any action
+	Parent  int       // the state in which the action was made
+	Seq     int       // the action's undo sequence number
+	Time    time.Time // when the action was made
+	Current bool      // the state is the current one
+	Redo    bool      // Redo from the parent goes to this state
+}
+
+// History returns the states of the undo history in the order they
+// were made, starting with the root.
+func (b *Buffer) History() []State {
+	states := make([]State, 0, len(b.actions)+1)
+	for _, a := range append([]*action{b.root}, b.actions...) {
+		st := State{
+			ID:      a.id,
+			Seq:     a.seq,
+			Time:    a.time,
+			Current: a == b.head,
+		}
+		if a.parent != nil {
+			st.Parent = a.parent.id
+			st.Redo = a.parent.redo == a
+		}
+		states = append(states, st)
+	}
+	return states
 }
 
 // SetUndoPoint commits the currently performed changes and creates an undo/redo point.
@@ -583,18 +704,13 @@ func (b *Buffer) SetUndoPoint() {
 
 // Clean marks the buffer as non-dirty.
 func (b *Buffer) Clean() {
-	if b.head > 0 {
-		b.savedAction = b.actions[b.head-1]
-	} else {
-		b.savedAction = nil
-	}
+	b.savedAction = b.head
 }
 
 // Dirty reports whether the current state of the buffer is different from the
 // initial state or from the one in the time of calling Clean.
 func (b *Buffer) Dirty() bool {
-	return b.head == 0 && b.savedAction != nil ||
-		b.head > 0 && b.savedAction != b.actions[b.head-1]
+	return b.head != b.savedAction
 }
 
 // TODO(rjk): It's possible to speed this up with the cached view.
@@ -614,7 +730,7 @@ func (b *Buffer) ReadAt(data []byte, off int64) (n int, err error) {
 	}
 
 	for n < len(data) && p != nil {
-		n += copy(data[n:], p.data[off:])
+		n += copy(data[n:], p.bytes()[off:])
 		p = p.next
 		off = 0
 	}
@@ -654,13 +770,11 @@ func (b *Buffer) Nr() int {
 
 // UnsetName records a filename change at seq to fname.
 func (b *Buffer) UnsetName(fname string, seq int) {
-	a := &action{
+	b.addAction(&action{
 		seq:   seq,
 		kind:  sam.Filename,
 		fname: fname,
-	}
-	b.actions = append(b.actions[:b.head], a)
-	b.head++
+	})
 
 	b.cachedPiece = nil
 	b.currentAction = nil
@@ -671,7 +785,7 @@ func (b *Buffer) UnsetName(fname string, seq int) {
 func (b *Buffer) validateInvariant() {
 	if expensiveCheckedExecution {
 		for p := b.begin; p != b.end; p = p.next {
-			if p.nr != utf8.RuneCount(p.data) {
+			if p.nr != utf8.RuneCount(p.bytes()) {
 				log.Printf("invariant violated in piece %#v", *p)
 				panic("file.Buffer piece invariant violated")
 			}
@@ -682,12 +796,24 @@ func (b *Buffer) validateInvariant() {
 // action is a list of changes which are used to undo/redo all modifications.
 type action struct {
 	changes []*change
+	edits   []edit // the changes as text, for the undo journal
 	seq     int
 
+	id     int       // position in Buffer.actions, counting from 1
+	parent *action   // nil for the root
+	redo   *action   // the child Redo goes to
+	time   time.Time // when the action was made
+
 	kind  int
 	fname string
 }
 
+// edit replaces the bytes del at byte offset off with ins.
+type edit struct {
+	off      int
+	del, ins []byte
+}
+
 // change keeps all needed information to redo/undo an insertion/deletion.
 type change struct {
 	old  span // all pieces which are being modified/swapped out by the change
@@ -765,12 +891,34 @@ type piece struct {
 	prev, next *piece
 	data       []byte
 	nr         int
+
+	// A piece of a large file reads data from file when it is needed.
+	file    *pagedFile
+	off     int64         // where data starts in file
+	size    int           // len(data) once read
+	pagedin *list.Element // in file.pagedin while data is held
 }
 
 func (p *piece) len() int {
+	if p.file != nil {
+		return p.size
+	}
 	return len(p.data)
 }
 
+// bytes returns the data of p, reading it first if p is a piece of a
+// large file.
+func (p *piece) bytes() []byte {
+	if p.file != nil {
+		if p.data == nil {
+			p.file.pagein(p)
+		} else {
+			p.file.pagedin.MoveToFront(p.pagedin)
+		}
+	}
+	return p.data
+}
+
 func (p *piece) insert(off int, data []byte, nr int) {
 	p.data = append(p.data[:off], append(data, p.data[off:]...)...)
 	p.nr += nr
@@ -789,7 +937,7 @@ func (p *piece) delete(off int, length int, nr int) bool {
 func (b *Buffer) Bytes() []byte {
 	byteBuf := make([]byte, 0, b.Size())
 	for p := b.begin; p != b.end; p = p.next {
-		byteBuf = append(byteBuf, p.data...)
+		byteBuf = append(byteBuf, p.bytes()...)
 	}
 	return byteBuf
 }
@@ -797,13 +945,13 @@ func (b *Buffer) Bytes() []byte {
 // HasUndoableChanges returns true if there are changes to the File
 // that can be undone.
 func (b *Buffer) HasUndoableChanges() bool {
-	return b.head != 0
+	return b.head != b.root
 }
 
 // HasRedoableChanges returns true if there are entries in the Redo
 // log that can be redone.
 func (b *Buffer) HasRedoableChanges() bool {
-	return b.head <= len(b.actions)-1
+	return b.head.redo != nil
 }
 
 func iabs(a int) int {
@@ -831,7 +979,7 @@ func (b *Buffer) ReadRuneAt(off OffsetTuple) (rune, int, error) {
 		tb = 0
 	}
 
-	r, sz := utf8.DecodeRune(p.data[tb:])
+	r, sz := utf8.DecodeRune(p.bytes()[tb:])
 	return r, sz, nil
 }
 
@@ -861,19 +1009,19 @@ func (b *Buffer) RuneTuple(off int) OffsetTuple {
 
 		// Find the byte offset in piece p
 		for i := b.vwl.B - b.vws.B; i >= 0 && b.vwl.R > off; {
-			_, sz := utf8.DecodeLastRune(p.data[0:i])
+			_, sz := utf8.DecodeLastRune(p.bytes()[0:i])
 			b.vwl = Ot(b.vwl.B-sz, b.vwl.R-1)
 			i -= sz
 		}
 	} else { // Go forwards.
 		for ; p != b.end && off > b.vws.R+p.nr; p = p.next {
-			b.vws = Ot(b.vws.B+len(p.data), b.vws.R+p.nr)
+			b.vws = Ot(b.vws.B+p.len(), b.vws.R+p.nr)
 			b.vwl = b.vws
 		}
 
 		// Find the byte offset in piece p
 		for i := b.vwl.B - b.vws.B; b.vwl.R < off; {
-			_, sz := utf8.DecodeRune(p.data[i:])
+			_, sz := utf8.DecodeRune(p.bytes()[i:])
 			b.vwl = Ot(b.vwl.B+sz, b.vwl.R+1)
 			i += sz
 		}
@@ -913,7 +1061,7 @@ func (b *Buffer) ByteTuple(off int) OffsetTuple {
 
 		// Find the byte offset in piece p
 		for i := b.vwl.B - b.vws.B; i >= 0 && b.vwl.B > off; {
-			_, sz := utf8.DecodeLastRune(p.data[0:i])
+			_, sz := utf8.DecodeLastRune(p.bytes()[0:i])
 			b.vwl = Ot(b.vwl.B-sz, b.vwl.R-1)
 			i -= sz
 		}
@@ -925,7 +1073,7 @@ func (b *Buffer) ByteTuple(off int) OffsetTuple {
 
 		// Find the byte offset in piece p
 		for i := b.vwl.B - b.vws.B; b.vwl.B < off; {
-			_, sz := utf8.DecodeRune(p.data[i:])
+			_, sz := utf8.DecodeRune(p.bytes()[i:])
 			b.vwl = Ot(b.vwl.B+sz, b.vwl.R+1)
 			i += sz
 		}
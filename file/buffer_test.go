@@ -6,7 +6,10 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestOverall(t *testing.T) {
@@ -199,6 +202,56 @@ func TestSaving(t *testing.T) {
 	b.checkModified(t, 14, false)
 }
 
+func TestUndoTree(t *testing.T) {
+	tick := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	b := NewBufferNoNr([]byte("base"))
+	b.insertString(4, " one", t) // 1
+	b.insertString(8, " two", t) // 2
+	b.Undo(0)
+	b.insertString(8, " three", t) // 3, a branch from 1
+	b.checkContent("#0", t, "base one three")
+
+	// Undo keeps the undone branch and Redo follows the newest.
+	b.Undo(0)
+	b.checkContent("#1", t, "base one")
+	b.Redo(0)
+	b.checkContent("#2", t, "base one three")
+
+	_, _, ok, _ := b.UndoTo(2)
+	if !ok {
+		t.Errorf("UndoTo(2) failed")
+	}
+	b.checkContent("#3", t, "base one two")
+	b.Undo(0)
+	b.Redo(0)
+	b.checkContent("#4", t, "base one two")
+
+	b.UndoTo(0)
+	b.checkContent("#5", t, "base")
+	b.Redo(0)
+	b.Redo(0)
+	b.checkContent("#6", t, "base one two")
+	if _, _, ok, _ := b.UndoTo(4); ok {
+		t.Errorf("UndoTo of a state that doesn't exist succeeded")
+	}
+
+	want := []State{
+		{ID: 0, Time: tick.Add(-3 * time.Second)},
+		{ID: 1, Parent: 0, Seq: 1, Time: tick.Add(-2 * time.Second), Redo: true},
+		{ID: 2, Parent: 1, Seq: 1, Time: tick.Add(-1 * time.Second), Current: true, Redo: true},
+		{ID: 3, Parent: 1, Seq: 1, Time: tick},
+	}
+	if diff := cmp.Diff(want, b.History()); diff != "" {
+		t.Errorf("History mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestReader(t *testing.T) {
 	b := NewBufferNoNr(nil)
 	b.insertString(0, "So many", t)
@@ -10,6 +10,9 @@ type DiskDetails struct {
 	Info  os.FileInfo
 	Hash  Hash // Used to check if the file has changed on disk since loaded.
 	isdir bool // Used to track if this File is populated from a directory list. [private]
+
+	read    *readState // what Load read of the file, for LoadMore
+	changed bool       // whether another program changed the file under unsaved edits
 }
 
 // IsDir returns true if the File has a synthetic backing of
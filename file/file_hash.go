@@ -3,6 +3,7 @@ package file
 import (
 	"bytes"
 	"crypto/sha1"
+	"encoding/hex"
 	"io"
 	"os"
 )
@@ -22,6 +23,11 @@ func (h Hash) Eq(h1 Hash) bool {
 	return bytes.Equal(h[:], h1[:])
 }
 
+// String returns h in hexadecimal.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
 func CalcHash(b []byte) Hash {
 	return sha1.Sum(b)
 }
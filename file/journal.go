@@ -0,0 +1,179 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rjkroege/edwood/sam"
+)
+
+// A Journal is the undo history of a file, saved so that a later
+// session can undo past the point at which it loaded the file. It holds
+// the undoable actions that lead to the file's contents, as edits. It
+// is only valid for a file whose contents still have Hash.
+type Journal struct {
+	Name    string          // absolute file name
+	Hash    string          // hex SHA-1 of the contents the history ends at
+	Actions []JournalAction // oldest first
+}
+
+// A JournalAction is the edits of one undoable action, in the order they
+// were made.
+type JournalAction []JournalEdit
+
+// A JournalEdit replaces the text Del at byte offset Off with Ins.
+type JournalEdit struct {
+	Off int
+	Del string `json:",omitempty"`
+	Ins string `json:",omitempty"`
+}
+
+// Journal returns the undo history that leads to the current contents
+// of e, or nil if there is none: the branch of the history tree from the
+// root, or the most recent change of file name, to the current state.
+func (e *ObservableEditableBuffer) Journal() *Journal {
+	b := e.f
+	if e.seq < 1 || b.paged != nil {
+		return nil
+	}
+	var path []*action
+	for a := b.head; a != b.root && a.kind != sam.Filename; a = a.parent {
+		path = append(path, a)
+	}
+	if len(path) == 0 {
+		return nil
+	}
+
+	j := &Journal{
+		Name: e.Name(),
+		Hash: CalcHash(b.Bytes()).String(),
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		ja := make(JournalAction, 0, len(path[i].edits))
+		for _, ed := range path[i].edits {
+			ja = append(ja, JournalEdit{ed.off, string(ed.del), string(ed.ins)})
+		}
+		j.Actions = append(j.Actions, ja)
+	}
+	return j
+}
+
+// RestoreJournal replaces the (empty) undo history of e with that in j,
+// so that Undo steps back through j's actions. The contents of e must
+// be those the journal ends at. nextseq provides the undo sequence
+// number of each restored action. e is left clean.
+func (e *ObservableEditableBuffer) RestoreJournal(j *Journal, nextseq func() int) error {
+	if e.f.paged != nil {
+		// A large file keeps no undo history.
+		return nil
+	}
+	cur := e.f.Bytes()
+	if CalcHash(cur).String() != j.Hash {
+		return fmt.Errorf("undo journal for %s is for other contents", j.Name)
+	}
+
+	// Undo the journal's actions as text to find where it starts.
+	text := cur
+	for i := len(j.Actions) - 1; i >= 0; i-- {
+		a := j.Actions[i]
+		for k := len(a) - 1; k >= 0; k-- {
+			ed := a[k]
+			end := ed.Off + len(ed.Ins)
+			if ed.Off < 0 || end > len(text) || string(text[ed.Off:end]) != ed.Ins {
+				return fmt.Errorf("undo journal for %s does not match its contents", j.Name)
+			}
+			text = bytes.Join([][]byte{text[:ed.Off], []byte(ed.Del), text[end:]}, nil)
+		}
+	}
+
+	// Then redo them on a new Buffer to build its undo history.
+	b := NewBuffer(text, len(bytes.Runes(text)))
+	seq := 0
+	for _, a := range j.Actions {
+		seq = nextseq()
+		b.Mark()
+		for _, ed := range a {
+			p0 := b.ByteTuple(ed.Off)
+			if ed.Del != "" {
+				b.Delete(p0, b.ByteTuple(ed.Off+len(ed.Del)), seq)
+			}
+			if ed.Ins != "" {
+				ins := []byte(ed.Ins)
+				b.Insert(p0, ins, len(bytes.Runes(ins)), seq)
+			}
+		}
+	}
+	b.Mark()
+	if !bytes.Equal(b.Bytes(), cur) {
+		return fmt.Errorf("undo journal for %s does not replay", j.Name)
+	}
+
+	before := e.getTagStatus()
+	defer e.notifyTagObservers(before)
+
+	b.oeb = e
+	e.f = b
+	e.seq = seq
+	e.putseq = seq
+	e.treatasclean = false
+	return nil
+}
+
+// JournalPath returns the file in dir that holds the undo journal of
+// the file name, which should be absolute.
+func JournalPath(dir, name string) string {
+	h := sha1.Sum([]byte(name))
+	return filepath.Join(dir, hex.EncodeToString(h[:]))
+}
+
+// ReadJournal reads the undo journal of the file name from dir. A
+// missing journal is nil.
+func ReadJournal(dir, name string) (*Journal, error) {
+	b, err := os.ReadFile(JournalPath(dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j Journal
+	if err := json.Unmarshal(b, &j); err != nil {
+		return nil, fmt.Errorf("bad undo journal for %s: %v", name, err)
+	}
+	if j.Name != name {
+		// A collision of the hashed names.
+		return nil, nil
+	}
+	return &j, nil
+}
+
+// WriteJournal writes j to dir, creating dir if needed.
+func WriteJournal(dir string, j *Journal) error {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	p := JournalPath(dir, j.Name)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// RemoveJournal removes the undo journal of the file name from dir.
+func RemoveJournal(dir, name string) error {
+	err := os.Remove(JournalPath(dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
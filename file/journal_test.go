@@ -0,0 +1,118 @@
+package file
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// editJournaled makes three undoable actions on a loaded file: typing,
+// erasing some of it, and a replacement.
+func editJournaled() *ObservableEditableBuffer {
+	f := MakeObservableEditableBuffer("/home/gopher/a.txt", nil)
+	f.InsertAt(0, []rune("one two 海老麺\n"))
+	f.Clean()
+
+	f.Mark(1)
+	for i, r := range "xyz" {
+		f.InsertAt(4+i, []rune{r})
+	}
+	f.DeleteAt(6, 7) // erase the z
+	f.Mark(2)
+	f.DeleteAt(0, 4)
+	f.Mark(3)
+	f.DeleteAt(6, 9)
+	f.InsertAt(6, []rune("ramen"))
+	return f
+}
+
+func TestJournal(t *testing.T) {
+	f := editJournaled()
+	if got, want := f.String(), "xytwo ramen\n"; got != want {
+		t.Fatalf("contents are %q; want %q", got, want)
+	}
+
+	j := f.Journal()
+	want := []JournalAction{
+		{{Off: 4, Ins: "xy"}},
+		{{Off: 0, Del: "one "}},
+		{{Off: 6, Del: "海老麺", Ins: "ramen"}},
+	}
+	if diff := cmp.Diff(want, j.Actions); diff != "" {
+		t.Errorf("journal actions mismatch (-want +got):\n%s", diff)
+	}
+
+	// Nothing to journal after undoing everything.
+	for i := 0; i < 3; i++ {
+		f.Undo(true)
+	}
+	if j := f.Journal(); j != nil {
+		t.Errorf("journal after undoing everything is %v; want nil", j)
+	}
+}
+
+func TestRestoreJournal(t *testing.T) {
+	dir := t.TempDir()
+	f := editJournaled()
+	if err := WriteJournal(dir, f.Journal()); err != nil {
+		t.Fatalf("WriteJournal failed: %v", err)
+	}
+
+	j, err := ReadJournal(dir, f.Name())
+	if err != nil || j == nil {
+		t.Fatalf("ReadJournal returned %v, %v", j, err)
+	}
+	if j, err := ReadJournal(dir, "/home/gopher/b.txt"); j != nil || err != nil {
+		t.Errorf("ReadJournal of a file without a journal returned %v, %v", j, err)
+	}
+
+	// A new session loads the file.
+	g := MakeObservableEditableBuffer(f.Name(), nil)
+	g.InsertAt(0, []rune(f.String()))
+	g.Clean()
+	seq := 10
+	if err := g.RestoreJournal(j, func() int { seq++; return seq }); err != nil {
+		t.Fatalf("RestoreJournal failed: %v", err)
+	}
+	check(t, "restored", g, &stateSummary{true, false, false, "xytwo ramen\n"})
+	if got, want := g.Seq(), 13; got != want {
+		t.Errorf("seq after restore is %d; want %d", got, want)
+	}
+
+	for _, want := range []string{"xytwo 海老麺\n", "one xytwo 海老麺\n", "one two 海老麺\n"} {
+		g.Undo(true)
+		if got := g.String(); got != want {
+			t.Errorf("after undo, contents are %q; want %q", got, want)
+		}
+	}
+	if g.HasUndoableChanges() {
+		t.Errorf("undo went past the start of the journal")
+	}
+	g.Undo(false)
+	if got, want := g.String(), "one xytwo 海老麺\n"; got != want {
+		t.Errorf("after redo, contents are %q; want %q", got, want)
+	}
+
+	if err := RemoveJournal(dir, f.Name()); err != nil {
+		t.Errorf("RemoveJournal failed: %v", err)
+	}
+	if j, err := ReadJournal(dir, f.Name()); j != nil || err != nil {
+		t.Errorf("ReadJournal after RemoveJournal returned %v, %v", j, err)
+	}
+}
+
+func TestRestoreJournalMismatch(t *testing.T) {
+	j := editJournaled().Journal()
+
+	g := MakeObservableEditableBuffer(j.Name, nil)
+	g.InsertAt(0, []rune("changed on disk\n"))
+	g.Clean()
+	err := g.RestoreJournal(j, func() int { return 1 })
+	if err == nil || !strings.Contains(err.Error(), "other contents") {
+		t.Errorf("RestoreJournal returned %v; want an error about other contents", err)
+	}
+	if g.HasUndoableChanges() {
+		t.Errorf("failed RestoreJournal left undoable changes")
+	}
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/rjkroege/edwood/util"
+)
+
+// tailSize is the number of bytes kept from the end of a loaded file.
+const tailSize = 4 << 10
+
+// readState is what Load read of a file: enough to tell whether the file
+// has only grown since, and to carry on reading it.
+type readState struct {
+	size int64     // bytes read
+	hash hash.Hash // SHA-1 of them
+	tail []byte    // the last tailSize of them
+}
+
+func newReadState() *readState {
+	return &readState{hash: sha1.New()}
+}
+
+// add records that d was read after what had been.
+func (rs *readState) add(d []byte) {
+	rs.hash.Write(d)
+	rs.size += int64(len(d))
+	rs.tail = append(rs.tail, d[max(0, len(d)-tailSize):]...)
+	if n := len(rs.tail); n > tailSize {
+		rs.tail = append(rs.tail[:0], rs.tail[n-tailSize:]...)
+	}
+}
+
+func (rs *readState) sum() (h Hash) {
+	h.Set(rs.hash.Sum(nil))
+	return h
+}
+
+// LoadMore adds to the end of e what has been added to the end of its
+// file since e was loaded, if e is clean and the file is the same one,
+// only longer. It returns the number of runes added and whether it could
+// add them. If not, the file has to be loaded again. fd is open on the
+// file.
+func (e *ObservableEditableBuffer) LoadMore(fd *os.File) (int, bool, error) {
+	rs := e.details.read
+	if rs == nil || e.Dirty() || int64(e.f.Size()) != rs.size || !e.details.Hash.Eq(rs.sum()) {
+		return 0, false, nil
+	}
+	d, err := fd.Stat()
+	if err != nil || e.details.Info == nil || !os.SameFile(d, e.details.Info) || d.Size() <= rs.size {
+		return 0, false, nil
+	}
+	pf := e.f.paged
+	if pf != nil && (pf.stale || !os.SameFile(d, pf.info)) {
+		return 0, false, nil
+	}
+
+	// Check that the end of what was loaded is still there.
+	now := make([]byte, len(rs.tail))
+	if _, err := fd.ReadAt(now, rs.size-int64(len(now))); err != nil || !bytes.Equal(rs.tail, now) {
+		return 0, false, nil
+	}
+
+	var nr int
+	if pf != nil {
+		pages, nb, n, err := pf.readPages(e.f, rs, d.Size())
+		if err == errNulls {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		e.insertPages(pf, pages, nb, n)
+		nr = n
+	} else {
+		b := make([]byte, d.Size()-rs.size)
+		n, err := fd.ReadAt(b, rs.size)
+		if err != nil && err != io.EOF {
+			return 0, false, err
+		}
+		b = b[:n]
+		if bytes.IndexByte(b, 0) >= 0 {
+			// Load elides NULs, which LoadMore cannot.
+			return 0, false, nil
+		}
+		runes, _, _ := util.Cvttorunes(b, len(b))
+		e.InsertAt(e.f.Nr(), runes)
+		rs.add(b)
+		nr = len(runes)
+	}
+	e.details.Hash = rs.sum()
+	e.details.Info = d
+	return nr, true, nil
+}
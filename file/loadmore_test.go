@@ -0,0 +1,84 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMoreSmall(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	appendFile := func(s string) {
+		t.Helper()
+		fd, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		if _, err := fd.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendFile("one 世界\n")
+	fd, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := MakeObservableEditableBuffer(name, nil)
+	f.Load(0, fd, true)
+	fd.Close()
+	f.Clean()
+
+	loadMore := func() (int, bool) {
+		t.Helper()
+		fd, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		n, ok, err := f.LoadMore(fd)
+		if err != nil {
+			t.Fatalf("LoadMore failed: %v", err)
+		}
+		return n, ok
+	}
+
+	if _, ok := loadMore(); ok {
+		t.Errorf("LoadMore added to a file that has not grown")
+	}
+
+	appendFile("two\n")
+	if n, ok := loadMore(); !ok || n != 4 {
+		t.Errorf("LoadMore gave %d, %v; want 4, true", n, ok)
+	}
+	f.Clean()
+	if got, want := f.String(), "one 世界\ntwo\n"; got != want {
+		t.Errorf("contents are %q; want %q", got, want)
+	}
+	h, _ := HashFor(name)
+	if got := f.Hash(); !got.Eq(h) {
+		t.Errorf("hash is %v; want %v", got, h)
+	}
+
+	// Edits keep what is in the file from being added to.
+	f.Mark(1)
+	f.InsertAt(0, []rune("zero\n"))
+	appendFile("three\n")
+	if _, ok := loadMore(); ok {
+		t.Errorf("LoadMore added to an edited file")
+	}
+	f.Undo(true)
+	if _, ok := loadMore(); !ok {
+		t.Errorf("LoadMore did not add once the edit was undone")
+	}
+	f.Clean()
+
+	// A file that was truncated and has grown again must be loaded again.
+	if err := os.WriteFile(name, []byte("a new file that is longer than the old one\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadMore(); ok {
+		t.Errorf("LoadMore added to a rewritten file")
+	}
+}
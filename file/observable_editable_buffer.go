@@ -43,11 +43,13 @@ type ObservableEditableBuffer struct {
 
 // A ObservableEditableBuffer can have a specific file-backing name that
 // permits it to be persisted to disk but typically would not be. These
-// two constants are suffixes of disk-file names that have this property.
+// constants are suffixes of disk-file names that have this property.
 // TODO(rjk): Consider making this a detail of file.Details?
 const (
 	slashguide = "/guide"
 	plusErrors = "+Errors"
+	plusUndo   = "+Undo"
+	plusEdit   = "+Edit"
 )
 
 // Set is a forwarding function for file_hash.Set
@@ -151,6 +153,7 @@ func (e *ObservableEditableBuffer) Clean() {
 	before := e.getTagStatus()
 
 	e.treatasclean = false
+	e.details.changed = false
 	op := e.putseq
 	e.putseq = e.seq
 
@@ -168,6 +171,7 @@ func (e *ObservableEditableBuffer) getTagStatus() TagStatus {
 		UndoableChanges:  e.HasUndoableChanges(),
 		RedoableChanges:  e.HasRedoableChanges(),
 		SaveableAndDirty: e.SaveableAndDirty(),
+		ChangedOnDisk:    e.details.changed,
 	}
 }
 
@@ -258,23 +262,52 @@ func (e *ObservableEditableBuffer) SaveableAndDirty() bool {
 //
 // TODO(rjk): Consider renaming InsertAtFromFd or something similar.
 //
-// TODO(rjk): Read and insert in chunks.
-//
 // TODO(flux): Innefficient to load the file, then copy into the slice,
 // but I need the UTF-8 interpretation. I could fix this by using a UTF-8
 // -> []rune reader on top of the os.File instead.
+//
+// A regular file of LargeFileSize bytes or more loaded into an empty
+// File is paged in rather than read. See pagein.go. If sethash is true,
+// Load also records what it read of a regular file for LoadMore.
 func (e *ObservableEditableBuffer) Load(q0 int, fd io.Reader, sethash bool) (int, bool, error) {
-	d, err := io.ReadAll(fd)
+	f, _ := fd.(*os.File)
+	var d os.FileInfo
+	if f != nil && q0 == 0 && e.f.Size() == 0 {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			d = info
+			// Read no more than LoadMore will take to have been read.
+			fd = io.LimitReader(f, d.Size())
+		}
+	}
+	e.details.read = nil
+
+	if d != nil && d.Size() >= LargeFileSize {
+		n, rs, err := e.loadLarge(f, d)
+		if err != errNulls {
+			if err == nil && sethash {
+				e.details.read, e.details.Info, e.details.Hash = rs, d, rs.sum()
+			}
+			return n, false, err
+		}
+		// Read it into memory, eliding the NULs.
+	}
+
+	b, err := io.ReadAll(fd)
 	// TODO(rjk): improve handling of read errors.
 	if err != nil {
 		err = errors.New("read error in RuneArray.Load")
 	}
 	if sethash {
-		e.SetHash(CalcHash(d))
+		e.SetHash(CalcHash(b))
 	}
 
-	runes, _, hasNulls := util.Cvttorunes(d, len(d))
+	runes, _, hasNulls := util.Cvttorunes(b, len(b))
 	e.InsertAt(q0, runes)
+	if d != nil && err == nil && sethash {
+		rs := newReadState()
+		rs.add(b)
+		e.details.read, e.details.Info = rs, d
+	}
 	return len(runes), hasNulls, err
 }
 
@@ -340,6 +373,20 @@ func (e *ObservableEditableBuffer) Undo(isundo bool) (q0, q1 int, ok bool) {
 	return q0, q1, ok
 }
 
+// UndoTo is a forwarding function for file.UndoTo.
+func (e *ObservableEditableBuffer) UndoTo(id int) (q0, q1 int, ok bool) {
+	before := e.getTagStatus()
+	defer e.notifyTagObservers(before)
+
+	q0, q1, ok, e.seq = e.f.UndoTo(id)
+	return q0, q1, ok
+}
+
+// History is a forwarding function for file.History.
+func (e *ObservableEditableBuffer) History() []State {
+	return e.f.History()
+}
+
 // DeleteAt is a forwarding function for buffer.DeleteAt.
 // rp0, rp1 are in runes.
 func (e *ObservableEditableBuffer) DeleteAt(rp0, rp1 int) {
@@ -387,6 +434,20 @@ func (e *ObservableEditableBuffer) Info() os.FileInfo {
 	return e.details.Info
 }
 
+// ChangedOnDisk reports whether another program has changed the file
+// while e had unsaved changes. Clean resets it.
+func (e *ObservableEditableBuffer) ChangedOnDisk() bool {
+	return e.details.changed
+}
+
+// SetChangedOnDisk sets whether another program has changed the file
+// while e had unsaved changes, updating the tags.
+func (e *ObservableEditableBuffer) SetChangedOnDisk(changed bool) {
+	before := e.getTagStatus()
+	defer e.notifyTagObservers(before)
+	e.details.changed = changed
+}
+
 // UpdateInfo is a forwarding function for DiskDetails.UpdateInfo
 func (e *ObservableEditableBuffer) UpdateInfo(filename string, d os.FileInfo) error {
 	return e.details.UpdateInfo(filename, d)
@@ -496,7 +557,7 @@ func (e *ObservableEditableBuffer) setfilename(name string) {
 	e.treatasclean = false
 
 	e.details.Name = name
-	if strings.HasSuffix(name, slashguide) || strings.HasSuffix(name, plusErrors) {
+	if strings.HasSuffix(name, slashguide) || strings.HasSuffix(name, plusErrors) || strings.HasSuffix(name, plusUndo) || strings.HasSuffix(name, plusEdit) {
 		e.isscratch = true
 	} else {
 		e.isscratch = false
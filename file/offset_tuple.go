@@ -23,7 +23,7 @@ func Ot(b, r int) OffsetTuple {
 
 func (o OffsetTuple) decrement(p *piece) OffsetTuple {
 	return Ot(
-		o.B-len(p.data),
+		o.B-p.len(),
 		o.R-p.nr,
 	)
 }
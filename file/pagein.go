@@ -0,0 +1,219 @@
+package file
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"unicode/utf8"
+)
+
+// Large files.
+//
+// Load reads a file of LargeFileSize bytes or more only once, to count
+// its runes and compute its hash. It is then held as pieces of pageSize
+// bytes that refer to the file rather than hold its text. A piece reads
+// its text from the file when it is needed, such as to draw the lines
+// of a frame, and at most maxPagedIn of them keep it, so the memory used
+// does not grow with the size of the file. Edits are made as usual: the
+// new pieces hold their own text.
+//
+// The file is read through a handle of its own, so renaming or removing
+// it does not change what the buffer holds. Writing into it in place or
+// truncating it does, so Put writes a new file instead (see PagesIn). A
+// piece that can no longer be read as it was is filled with replacement
+// runes rather than the text that was lost.
+//
+// A buffer holding a large file keeps no undo journal, and loading one
+// discards the undo history of the buffer.
+
+// LargeFileSize is the size in bytes from which Load pages a file in
+// rather than reading it into memory.
+var LargeFileSize int64 = 32 << 20
+
+const pageSize = 64 << 10 // bytes in a piece of a large file
+
+// maxPagedIn is the number of pieces of a large file that keep their
+// text. It is changed in tests.
+var maxPagedIn = 256
+
+// errNulls is returned by loadLarge for a file with NUL bytes, which
+// Load elides and so cannot leave in the file.
+var errNulls = errors.New("file has NUL bytes")
+
+// pagedFile is a large file that pieces read their text from.
+type pagedFile struct {
+	f       *os.File
+	info    os.FileInfo
+	pagedin *list.List // the pieces holding text, most recently used first
+	stale   bool       // whether the file could not be read as it was
+}
+
+// openPaged opens the file d, which fd is open on, for paging in.
+func openPaged(fd *os.File, d os.FileInfo) (*pagedFile, error) {
+	f, err := os.Open(fd.Name())
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || !os.SameFile(d, info) {
+		f.Close()
+		return nil, errors.New("file replaced while loading")
+	}
+	return &pagedFile{
+		f:       f,
+		info:    info,
+		pagedin: list.New(),
+	}, nil
+}
+
+// readPages reads the file from rs.size to end and returns pieces for
+// it, adding what it reads to rs. If it fails, pf is stale.
+func (pf *pagedFile) readPages(b *Buffer, rs *readState, end int64) (pages []*piece, nb, nr int, err error) {
+	buf := make([]byte, pageSize)
+	for rs.size < end {
+		buf := buf[:min(int64(len(buf)), end-rs.size)]
+		n, err := pf.f.ReadAt(buf, rs.size)
+		if err != nil && err != io.EOF {
+			pf.stale = true
+			return nil, 0, 0, err
+		}
+		if n == 0 {
+			break
+		}
+		if rs.size+int64(n) < end {
+			n = pageEnd(buf[:n])
+		}
+		d := buf[:n]
+		if bytes.IndexByte(d, 0) >= 0 {
+			pf.stale = true
+			return nil, 0, 0, errNulls
+		}
+		c := utf8.RuneCount(d)
+		pages = append(pages, b.newPagedPiece(pf, rs.size, n, c))
+		rs.add(d)
+		nb += n
+		nr += c
+	}
+	return pages, nb, nr, nil
+}
+
+// pageEnd returns where to end a piece of the bytes in buf, not all of
+// which are the end of the file, such that no rune spans the end.
+func pageEnd(buf []byte) int {
+	n := len(buf)
+	for k := n - 1; k >= n-utf8.UTFMax; k-- {
+		if utf8.RuneStart(buf[k]) {
+			return k
+		}
+	}
+	// None of the last bytes can start a rune, so none can run past n.
+	return n
+}
+
+// pagein reads the text of p from the file, making room for it by
+// dropping the text of the pieces used least recently.
+func (pf *pagedFile) pagein(p *piece) {
+	d := make([]byte, p.size)
+	n, _ := pf.f.ReadAt(d, p.off)
+	if n < len(d) || utf8.RuneCount(d) != p.nr {
+		if !pf.stale {
+			log.Printf("%s changed on disk while open as a large file", pf.f.Name())
+			pf.stale = true
+		}
+		d = filler(p.size, p.nr)
+	}
+	p.data = d
+	p.pagedin = pf.pagedin.PushFront(p)
+	for pf.pagedin.Len() > maxPagedIn {
+		q := pf.pagedin.Remove(pf.pagedin.Back()).(*piece)
+		q.data, q.pagedin = nil, nil
+	}
+}
+
+// filler returns size bytes of nr replacement runes, for a piece whose
+// text can no longer be read. Each rune is as wide as it needs to be to
+// fill size bytes.
+func filler(size, nr int) []byte {
+	d := make([]byte, 0, size)
+	for ; nr > 0; nr-- {
+		w := max(1, min(utf8.UTFMax, size-len(d)-(nr-1)))
+		d = utf8.AppendRune(d, fillerRunes[w])
+	}
+	return d
+}
+
+var fillerRunes = [...]rune{1: '?', 2: '¿', 3: utf8.RuneError, 4: '\U000FFFFD'}
+
+// newPagedPiece makes a piece for the size bytes at off in pf, which
+// hold nr runes.
+func (b *Buffer) newPagedPiece(pf *pagedFile, off int64, size, nr int) *piece {
+	p := b.newEmptyPiece()
+	p.file, p.off, p.size, p.nr = pf, off, size, nr
+	return p
+}
+
+// appendPages adds pages to the end of b, which pages in from pf, and
+// discards the undo history, which does not include them.
+func (b *Buffer) appendPages(pf *pagedFile, pages []*piece, nb, nr int) {
+	if len(pages) == 0 {
+		return
+	}
+	end := b.End()
+	last := b.end.prev
+	for _, p := range pages {
+		p.prev, last.next = last, p
+		last = p
+	}
+	last.next, b.end.prev = b.end, last
+
+	b.paged = pf
+	b.pend = end.Add(nb, nr)
+	b.viewed = nil
+	b.cachedPiece = nil
+	b.FlattenHistory()
+	b.validateInvariant()
+}
+
+// loadLarge loads the file d, which fd is open on, at the end of e as
+// a large file. It returns the number of runes loaded and what it read.
+func (e *ObservableEditableBuffer) loadLarge(fd *os.File, d os.FileInfo) (int, *readState, error) {
+	pf, err := openPaged(fd, d)
+	if err != nil {
+		return 0, nil, err
+	}
+	rs := newReadState()
+	pages, nb, nr, err := pf.readPages(e.f, rs, d.Size())
+	if err != nil {
+		pf.f.Close()
+		return 0, nil, err
+	}
+	e.insertPages(pf, pages, nb, nr)
+	return nr, rs, nil
+}
+
+// insertPages adds pages to the end of e and tells the observers. They
+// are given only the text of the first page.
+func (e *ObservableEditableBuffer) insertPages(pf *pagedFile, pages []*piece, nb, nr int) {
+	if len(pages) == 0 {
+		return
+	}
+	before := e.getTagStatus()
+	defer e.notifyTagObservers(before)
+
+	p0 := e.f.End()
+	e.f.appendPages(pf, pages, nb, nr)
+	e.inserted(p0, pages[0].bytes(), nr)
+}
+
+// IsLarge reports whether e holds a large file that is paged in.
+func (e *ObservableEditableBuffer) IsLarge() bool {
+	return e.f.paged != nil
+}
+
+// PagesIn reports whether e pages in text from the file d.
+func (e *ObservableEditableBuffer) PagesIn(d os.FileInfo) bool {
+	return e.f.paged != nil && os.SameFile(e.f.paged.info, d)
+}
@@ -0,0 +1,201 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// largeText is text of several pages with runes of every width, many of
+// which span the ends of pages.
+var largeText = strings.Repeat("a¿日\U0001F600\n", 3*pageSize/10+7)
+
+// loadLargeFile writes text to a file and loads it into a new buffer as a
+// large file.
+func loadLargeFile(t *testing.T, text string) (*ObservableEditableBuffer, string) {
+	t.Helper()
+	old := LargeFileSize
+	LargeFileSize = 1
+	t.Cleanup(func() { LargeFileSize = old })
+
+	name := filepath.Join(t.TempDir(), "large")
+	if err := os.WriteFile(name, []byte(text), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	f := MakeObservableEditableBuffer(name, nil)
+	n, _, err := f.Load(0, fd, true)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	// NULs are elided.
+	if got, want := n, utf8.RuneCountInString(strings.ReplaceAll(text, "\x00", "")); got != want {
+		t.Errorf("Load read %d runes; want %d", got, want)
+	}
+	f.Clean()
+	return f, name
+}
+
+func TestLoadLarge(t *testing.T) {
+	f, _ := loadLargeFile(t, largeText)
+	if !f.IsLarge() {
+		t.Fatal("file is not paged in")
+	}
+	if got, want := f.Hash(), CalcHash([]byte(largeText)); !got.Eq(want) {
+		t.Errorf("hash is %v; want %v", got, want)
+	}
+	if f.String() != largeText {
+		t.Errorf("contents differ from the file")
+	}
+	r := []rune(largeText)
+	if got, want := f.Nr(), len(r); got != want {
+		t.Errorf("Nr is %d; want %d", got, want)
+	}
+	for _, q := range []int{0, 1, pageSize / 3, pageSize/3 + 1, len(r) - 1} {
+		if got, want := f.ReadC(q), r[q]; got != want {
+			t.Errorf("ReadC(%d) is %q; want %q", q, got, want)
+		}
+	}
+}
+
+func TestLoadLargePagesOut(t *testing.T) {
+	old := maxPagedIn
+	maxPagedIn = 2
+	defer func() { maxPagedIn = old }()
+
+	f, _ := loadLargeFile(t, largeText)
+	if f.String() != largeText {
+		t.Errorf("contents differ from the file")
+	}
+	if got := f.f.paged.pagedin.Len(); got > maxPagedIn {
+		t.Errorf("%d pieces hold text; want at most %d", got, maxPagedIn)
+	}
+	held := 0
+	for p := f.f.begin; p != nil; p = p.next {
+		if p.file != nil && p.data != nil {
+			held++
+		}
+	}
+	if held > maxPagedIn {
+		t.Errorf("%d pieces hold text; want at most %d", held, maxPagedIn)
+	}
+}
+
+func TestLoadLargeEdit(t *testing.T) {
+	f, _ := loadLargeFile(t, largeText)
+	r := []rune(largeText)
+	q := pageSize / 3
+
+	f.Mark(1)
+	f.InsertAt(q, []rune("inserted"))
+	f.DeleteAt(10, 2*q)
+	r = append(r[:q], append([]rune("inserted"), r[q:]...)...)
+	r = append(r[:10], r[2*q:]...)
+	if got, want := f.String(), string(r); got != want {
+		t.Errorf("after editing, contents are wrong")
+	}
+	f.Undo(true)
+	if f.String() != largeText {
+		t.Errorf("after undo, contents differ from the file")
+	}
+	if j := f.Journal(); j != nil {
+		t.Errorf("large file has a journal")
+	}
+}
+
+func TestLoadMore(t *testing.T) {
+	f, name := loadLargeFile(t, largeText)
+	more := "more\n"
+
+	fd, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.WriteString(more)
+	fd.Close()
+
+	fd, err = os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ok, err := f.LoadMore(fd)
+	fd.Close()
+	if !ok || err != nil || n != len(more) {
+		t.Fatalf("LoadMore gave %d, %v, %v; want %d, true, nil", n, ok, err, len(more))
+	}
+	if f.String() != largeText+more {
+		t.Errorf("contents differ from the file")
+	}
+	if got, want := f.Hash(), CalcHash([]byte(largeText+more)); !got.Eq(want) {
+		t.Errorf("hash is %v; want %v", got, want)
+	}
+
+	// A file whose old text has changed must be loaded again.
+	if err := os.WriteFile(name, []byte(strings.ToUpper(largeText+more)+more), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fd, err = os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	if _, ok, _ := f.LoadMore(fd); ok {
+		t.Errorf("LoadMore added to a changed file")
+	}
+}
+
+func TestLoadLargeTruncated(t *testing.T) {
+	f, name := loadLargeFile(t, largeText)
+	if err := os.Truncate(name, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := f.String()
+	if got, want := len(s), len(largeText); got != want {
+		t.Errorf("truncated file has %d bytes; want %d", got, want)
+	}
+	if got, want := utf8.RuneCountInString(s), f.Nr(); got != want {
+		t.Errorf("truncated file has %d runes; want %d", got, want)
+	}
+}
+
+func TestLoadLargeNulls(t *testing.T) {
+	f, _ := loadLargeFile(t, "a\x00b")
+	if f.IsLarge() {
+		t.Error("file with NULs is paged in")
+	}
+	if got, want := f.String(), "ab"; got != want {
+		t.Errorf("contents are %q; want %q", got, want)
+	}
+}
+
+func TestFiller(t *testing.T) {
+	for _, tc := range []struct{ size, nr int }{{0, 0}, {1, 1}, {5, 2}, {8, 2}, {7, 7}, {10, 3}} {
+		d := filler(tc.size, tc.nr)
+		if len(d) != tc.size || utf8.RuneCount(d) != tc.nr {
+			t.Errorf("filler(%d, %d) gave %d bytes and %d runes", tc.size, tc.nr, len(d), utf8.RuneCount(d))
+		}
+	}
+}
+
+func TestPageEnd(t *testing.T) {
+	for _, tc := range []struct {
+		buf  string
+		want int
+	}{
+		{"abcd", 3},
+		{"ab日", 2},
+		{"a\U0001F600", 1},
+		{"\x80\x80\x80\x80", 4},
+	} {
+		if got := pageEnd([]byte(tc.buf)); got != tc.want {
+			t.Errorf("pageEnd(%q) is %d; want %d", tc.buf, got, tc.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ type TagStatus struct {
 	UndoableChanges  bool
 	RedoableChanges  bool
 	SaveableAndDirty bool
+	ChangedOnDisk    bool
 }
 
 // TagStatusObserver implementations can register themselves with an
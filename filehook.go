@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// File hooks are external commands run automatically when a window is
+// loaded with a matching file. They are how syntax colorers such as
+// edcolor and md2spans get attached to windows.
+//
+// The user's hook file ($HOME/lib/edwood/hooks) holds one rule per line:
+//
+//	# comment
+//	glob    *.go             edcolor
+//	shebang python*          edcolor
+//	dir     /home/me/proj    myfmt -w
+//	glob    *.md             -
+//
+// The first field is the kind of match, the second is the pattern and
+// the rest of the line is the command to run, with its arguments. A
+// command of "-" suppresses any later rule, including the built-in ones.
+//
+//   - glob patterns use filepath.Match syntax. A pattern without a slash
+//     is matched against the file's base name, otherwise against the
+//     whole path. Matching is case-insensitive.
+//   - shebang patterns are globs matched against the base name of the
+//     interpreter named on a "#!" first line. "#!/usr/bin/env python3"
+//     names python3.
+//   - dir patterns match any file inside the named directory.
+//
+// Rules are tried in order and the first match wins. User rules come
+// before the built-in defaults. The Hooks command re-reads the file.
+
+// hookKind says what a fileHook pattern is matched against.
+type hookKind int
+
+const (
+	hookGlob hookKind = iota
+	hookShebang
+	hookDir
+)
+
+var hookKindNames = []string{
+	hookGlob:    "glob",
+	hookShebang: "shebang",
+	hookDir:     "dir",
+}
+
+func (k hookKind) String() string {
+	return hookKindNames[k]
+}
+
+// parseHookKind returns the hookKind with the given name.
+func parseHookKind(s string) (hookKind, bool) {
+	for k, name := range hookKindNames {
+		if name == s {
+			return hookKind(k), true
+		}
+	}
+	return 0, false
+}
+
+// fileHook is a single rule from the hook table.
+type fileHook struct {
+	kind    hookKind
+	pattern string
+	command string
+}
+
+// noHookCommand is the command that stops hook matching without running
+// anything.
+const noHookCommand = "-"
+
+// defaultFileHooks are consulted after any user-configured rules.
+var defaultFileHooks = []fileHook{
+	{hookGlob, "*.go", "edcolor"},
+	{hookGlob, "*.py", "edcolor"},
+	{hookGlob, "*.rs", "edcolor"},
+	{hookGlob, "*.tex", "edcolor"},
+	{hookGlob, "*.sty", "edcolor"},
+	{hookGlob, "*.cls", "edcolor"},
+	{hookGlob, "*.[ch]", "edcolor"},
+	{hookGlob, "*.sh", "edcolor"},
+	{hookGlob, "*.bash", "edcolor"},
+	{hookGlob, "*.yaml", "edcolor"},
+	{hookGlob, "*.yml", "edcolor"},
+	{hookGlob, "*.ts", "edcolor"},
+	{hookGlob, "*.tsx", "edcolor"},
+	{hookGlob, "*.js", "edcolor"},
+	{hookGlob, "makefile", "edcolor"},
+	{hookGlob, "gnumakefile", "edcolor"},
+	{hookGlob, "*.mk", "edcolor"},
+	{hookGlob, "mkfile", "edcolor"},
+	{hookGlob, "*.md", "md2spans"},
+}
+
+// fileHookPath returns the location of the user's hook file.
+func fileHookPath() string {
+	return filepath.Join(global.home, "lib", "edwood", "hooks")
+}
+
+// parseFileHooks reads hook rules from r. name is used to label errors.
+func parseFileHooks(r io.Reader, name string) ([]fileHook, error) {
+	var hooks []fileHook
+	sc := bufio.NewScanner(r)
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s:%d: want kind, pattern and command", name, lineno)
+		}
+		kind, ok := parseHookKind(fields[0])
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown hook kind %q", name, lineno, fields[0])
+		}
+		pattern := fields[1]
+		switch kind {
+		case hookGlob, hookShebang:
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: bad pattern %q: %v", name, lineno, pattern, err)
+			}
+		case hookDir:
+			pattern = filepath.Clean(pattern)
+		}
+		hooks = append(hooks, fileHook{
+			kind:    kind,
+			pattern: pattern,
+			command: strings.Join(fields[2:], " "),
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return hooks, nil
+}
+
+// loadFileHooks builds the hook table from the file at path followed by
+// the built-in defaults. A missing file is not an error.
+func loadFileHooks(path string) ([]fileHook, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return defaultFileHooks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hooks, err := parseFileHooks(f, path)
+	if err != nil {
+		return nil, err
+	}
+	return append(hooks, defaultFileHooks...), nil
+}
+
+// shebangInterpreter returns the base name of the interpreter named by a
+// "#!" line, skipping over env. It returns "" if line is not a shebang.
+func shebangInterpreter(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				return filepath.Base(f)
+			}
+		}
+		return ""
+	}
+	return interp
+}
+
+// matches reports whether the hook applies to the file name whose first
+// line is firstline.
+func (h *fileHook) matches(name, firstline string) bool {
+	switch h.kind {
+	case hookGlob:
+		subject := name
+		if !strings.Contains(h.pattern, "/") {
+			subject = filepath.Base(name)
+		}
+		ok, _ := filepath.Match(strings.ToLower(h.pattern), strings.ToLower(subject))
+		return ok
+	case hookShebang:
+		interp := shebangInterpreter(firstline)
+		if interp == "" {
+			return false
+		}
+		ok, _ := filepath.Match(h.pattern, interp)
+		return ok
+	case hookDir:
+		rel, err := filepath.Rel(h.pattern, filepath.Clean(name))
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+	return false
+}
+
+// fileHookCommand returns the command line to run for the file name
+// whose first line is firstline, or "" if no hook applies.
+func fileHookCommand(hooks []fileHook, name, firstline string) string {
+	if name == "" {
+		return ""
+	}
+	for i := range hooks {
+		if hooks[i].matches(name, firstline) {
+			if hooks[i].command == noHookCommand {
+				return ""
+			}
+			return hooks[i].command
+		}
+	}
+	return ""
+}
+
+// fileHookTool returns the command configured for the given filename
+// without regard to its contents, or "" if no hook applies.
+func fileHookTool(name string) string {
+	return fileHookCommand(global.filehooks, name, "")
+}
+
+// firstLine returns the first line of the text, up to a bounded length.
+func (t *Text) firstLine() string {
+	const maxShebang = 256
+	n := min(t.file.Nr(), maxShebang)
+	r := make([]rune, n)
+	t.file.Read(0, r)
+	s := string(r)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// maybeRunFileHook checks if the window's file matches a configured file
+// hook, and if so, runs the corresponding command.
+func maybeRunFileHook(w *Window) {
+	if w == nil {
+		return
+	}
+	cmd := fileHookCommand(global.filehooks, w.body.file.Name(), w.body.firstLine())
+	if cmd == "" {
+		return
+	}
+	if _, err := exec.LookPath(strings.Fields(cmd)[0]); err != nil {
+		return
+	}
+	dir := w.body.DirName("")
+	w.ref.Inc()
+	run(w, cmd, dir, true, "", "", false)
+}
+
+// hookscmd re-reads the user's hook file and lists the resulting rules
+// in the +Errors window.
+func hookscmd(_, _, _ *Text, _, _ bool, _ string) {
+	path := fileHookPath()
+	hooks, err := loadFileHooks(path)
+	if err != nil {
+		warning(nil, "Hooks: %v\n", err)
+		return
+	}
+	global.filehooks = hooks
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hooks: %s\n", path)
+	for _, h := range hooks {
+		fmt.Fprintf(&b, "\t%v\t%s\t%s\n", h.kind, h.pattern, h.command)
+	}
+	warning(nil, "%s", b.String())
+}
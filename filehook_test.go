@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFileHooks(t *testing.T) {
+	const conf = `
+# comment
+glob    *.zig        zigcolor -x
+shebang python*      edcolor
+dir     /src/proj/   myfmt
+glob    *.md         -
+`
+	got, err := parseFileHooks(strings.NewReader(conf), "hooks")
+	if err != nil {
+		t.Fatalf("parseFileHooks failed: %v", err)
+	}
+	want := []fileHook{
+		{hookGlob, "*.zig", "zigcolor -x"},
+		{hookShebang, "python*", "edcolor"},
+		{hookDir, "/src/proj", "myfmt"},
+		{hookGlob, "*.md", "-"},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(fileHook{})); diff != "" {
+		t.Errorf("parseFileHooks mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseFileHooksErrors(t *testing.T) {
+	tests := []struct {
+		conf string
+		want string
+	}{
+		{"glob *.go", "hooks:1: want kind, pattern and command"},
+		{"\nsuffix .go edcolor", `hooks:2: unknown hook kind "suffix"`},
+		{"glob [ edcolor", `hooks:1: bad pattern "["`},
+	}
+	for _, tc := range tests {
+		_, err := parseFileHooks(strings.NewReader(tc.conf), "hooks")
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("parseFileHooks(%q) error = %v, want prefix %q", tc.conf, err, tc.want)
+		}
+	}
+}
+
+func TestFileHookCommand(t *testing.T) {
+	hooks := []fileHook{
+		{hookGlob, "*.md", "-"},
+		{hookGlob, "/src/gen/*.go", "-"},
+		{hookShebang, "python*", "edcolor"},
+		{hookDir, "/src/proj", "myfmt -w"},
+	}
+	hooks = append(hooks, defaultFileHooks...)
+
+	tests := []struct {
+		name      string
+		firstline string
+		want      string
+	}{
+		{"", "", ""},
+		{"/src/readme.md", "", ""},
+		{"/src/main.go", "", "edcolor"},
+		{"/src/gen/main.go", "", ""},
+		{"/bin/tool", "#!/usr/bin/python3", "edcolor"},
+		{"/bin/tool", "#!/usr/bin/env -S python3 -u", "edcolor"},
+		{"/bin/tool", "#!/bin/sh", ""},
+		{"/bin/tool", "# not a shebang", ""},
+		{"/src/proj/a/b.txt", "", "myfmt -w"},
+		{"/src/project/b.txt", "", ""},
+		{"/src/proj/x.go", "", "myfmt -w"},
+	}
+	for _, tc := range tests {
+		got := fileHookCommand(hooks, tc.name, tc.firstline)
+		if got != tc.want {
+			t.Errorf("fileHookCommand(%q, %q) = %q, want %q", tc.name, tc.firstline, got, tc.want)
+		}
+	}
+}
+
+func TestLoadFileHooks(t *testing.T) {
+	dir := t.TempDir()
+
+	hooks, err := loadFileHooks(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatalf("loadFileHooks on missing file failed: %v", err)
+	}
+	if diff := cmp.Diff(defaultFileHooks, hooks, cmp.AllowUnexported(fileHook{})); diff != "" {
+		t.Errorf("missing file should give defaults (-want +got):\n%s", diff)
+	}
+
+	path := filepath.Join(dir, "hooks")
+	if err := os.WriteFile(path, []byte("glob *.go gocolor\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err = loadFileHooks(path)
+	if err != nil {
+		t.Fatalf("loadFileHooks failed: %v", err)
+	}
+	if got, want := len(hooks), len(defaultFileHooks)+1; got != want {
+		t.Errorf("got %d hooks, want %d", got, want)
+	}
+	if got := fileHookCommand(hooks, "main.go", ""); got != "gocolor" {
+		t.Errorf("user hook should take precedence, got %q", got)
+	}
+	if got := fileHookCommand(hooks, "main.py", ""); got != "edcolor" {
+		t.Errorf("defaults should still apply, got %q", got)
+	}
+}
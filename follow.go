@@ -0,0 +1,78 @@
+package main
+
+import "time"
+
+// followInterval is how often the file of a followed window is checked.
+var followInterval = time.Second
+
+// follow toggles whether the window follows its file: while it does,
+// text added to the file is added to the body, and a body whose dot is
+// at the end scrolls to show it.
+func follow(et, _, _ *Text, _, _ bool, _ string) {
+	if et == nil || et.w == nil {
+		return
+	}
+	w := et.w
+	if w.follow != nil {
+		w.stopFollow()
+	} else {
+		w.startFollow()
+	}
+}
+
+// startFollow starts checking the file of w every followInterval. The
+// checks run on the main goroutine, like the preview renders, and hold a
+// reference to w until stopFollow. w must be locked.
+func (w *Window) startFollow() {
+	if w.follow != nil || w.body.file.IsDirOrScratch() {
+		return
+	}
+	stop := make(chan struct{})
+	w.follow = stop
+	w.ref.Inc()
+	go func() {
+		tick := time.NewTicker(followInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				global.previewRenderCh <- func() {
+					w.lk.Lock()
+					w.Close()
+					w.lk.Unlock()
+				}
+				return
+			case <-tick.C:
+				global.previewRenderCh <- func() {
+					w.followCheck(stop)
+				}
+			}
+		}
+	}()
+}
+
+// stopFollow stops w following its file. w must be locked.
+func (w *Window) stopFollow() {
+	if w.follow != nil {
+		close(w.follow)
+		w.follow = nil
+	}
+}
+
+// followCheck updates w from its file if w still follows it with stop.
+func (w *Window) followCheck(stop chan struct{}) {
+	global.row.lk.Lock()
+	defer global.row.lk.Unlock()
+	w.Lock('F')
+	defer w.Unlock()
+	if w.follow != stop {
+		return
+	}
+	if w.col == nil {
+		w.stopFollow()
+		return
+	}
+	if w.body.reloadFile() && w.IsPreviewMode() {
+		w.UpdatePreview()
+	}
+}
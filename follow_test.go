@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFollowFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(name, []byte("one\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin(name),
+		ScBody(name, ""),
+	)
+	w := global.row.col[0].w[0]
+	body := &w.body
+	body.Load(0, name, true)
+	body.file.Clean()
+	body.q0, body.q1 = 4, 4
+
+	check := func(what, want string, changed bool, q0 int) {
+		t.Helper()
+		if got := body.reloadFile(); got != changed {
+			t.Errorf("%s: reloadFile gave %v; want %v", what, got, changed)
+		}
+		if got := body.file.String(); got != want {
+			t.Errorf("%s: body is %q; want %q", what, got, want)
+		}
+		if body.file.Dirty() {
+			t.Errorf("%s: body is dirty", what)
+		}
+		if body.q0 != q0 || body.q1 != q0 {
+			t.Errorf("%s: dot is %d,%d; want %d,%d", what, body.q0, body.q1, q0, q0)
+		}
+	}
+	appendFile := func(s string) {
+		t.Helper()
+		fd, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		if _, err := fd.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check("unchanged", "one\n", false, 4)
+
+	appendFile("two\n")
+	check("grown", "one\ntwo\n", true, 8)
+
+	body.q0, body.q1 = 0, 0
+	appendFile("three\n")
+	check("grown with dot at start", "one\ntwo\nthree\n", true, 0)
+
+	if err := os.Truncate(name, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile("four\n")
+	check("truncated", "four\n", true, 0)
+
+	if err := os.Rename(name, name+".0"); err != nil {
+		t.Fatal(err)
+	}
+	check("removed", "four\n", false, 0)
+	if err := os.WriteFile(name, []byte("five\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	check("rotated", "five\n", true, 0)
+
+	global.seq++
+	body.file.Mark(global.seq)
+	body.file.InsertAt(0, []rune("edit\n"))
+	appendFile("six\n")
+	if body.reloadFile() {
+		t.Errorf("reloadFile changed a dirty body")
+	}
+}
+
+func TestFollowStop(t *testing.T) {
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin("/home/gopher/log"),
+		ScBody("/home/gopher/log", ""),
+	)
+	w := global.row.col[0].w[0]
+	refs := func() int {
+		w.ref.Inc()
+		return w.ref.Dec()
+	}
+	ref := refs()
+
+	follow(&w.body, nil, nil, false, false, "")
+	if w.follow == nil {
+		t.Fatal("Follow did not start following")
+	}
+	if got := refs(); got != ref+1 {
+		t.Errorf("following holds %d references; want %d", got, ref+1)
+	}
+
+	follow(&w.body, nil, nil, false, false, "")
+	if w.follow != nil {
+		t.Fatal("Follow did not stop following")
+	}
+	fn := <-global.previewRenderCh
+	fn()
+	if got := refs(); got != ref {
+		t.Errorf("stopping holds %d references; want %d", got, ref)
+	}
+}
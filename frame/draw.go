@@ -40,6 +40,40 @@ func (f *frameimpl) DrawSel(pt image.Point, p0, p1 int, highlighted bool) {
 	f.drawselimpl(pt, p0, p1, highlighted)
 }
 
+func (f *frameimpl) DrawSecondarySel(p0, p1 int, on bool) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	if p0 > p1 {
+		panic("DrawSecondarySel: p0 and p1 must be ordered")
+	}
+	p0 = min(p0, f.nchars)
+	p1 = min(p1, f.nchars)
+	back, text := f.cols[ColBack], f.cols[ColText]
+	if p0 < p1 {
+		if on {
+			back, text = f.cols[ColHigh], f.cols[ColHText]
+		}
+		f.drawsel0(f.ptofcharptb(p0, f.rect.Min, 0), p0, p1, back, text)
+		return
+	}
+
+	pt := f.ptofcharptb(p0, f.rect.Min, 0)
+	if !pt.In(f.rect) {
+		return
+	}
+	r := image.Rect(pt.X, pt.Y, pt.X+f.tickscale, pt.Y+f.defaultfontheight).Intersect(f.rect)
+	if on {
+		f.background.Draw(r, text, nil, image.Point{})
+		return
+	}
+	// Clear the bar, then restore the glyphs it may have overlapped.
+	f.background.Draw(r, back, nil, image.Point{})
+	q0, q1 := max(p0-1, 0), min(p0+1, f.nchars)
+	if q0 < q1 {
+		f.drawsel0(f.ptofcharptb(q0, f.rect.Min, 0), q0, q1, back, text)
+	}
+}
+
 func (f *frameimpl) drawselimpl(pt image.Point, p0, p1 int, highlighted bool) {
 	// log.Println("Frame DrawSel Start", p0, p1, highlighted, f.sp0, f.sp1, f.ticked)
 	// defer log.Println("Frame DrawSel End",  f.sp0, f.sp1, f.ticked)
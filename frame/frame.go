@@ -160,6 +160,13 @@ type Frame interface {
 	// multiple calls to DrawSel with highlighted false will be cheap.
 	// TODO(rjk): DrawSel does more drawing work than necessary.
 	DrawSel(image.Point, int, int, bool)
+
+	// DrawSecondarySel paints the runes p0 to p1 as a secondary selection,
+	// if on is true, or as plain text. An empty secondary selection is a
+	// bar before rune p0. Secondary selections are not tracked by the
+	// Frame: DrawSel, Insert and Delete may paint over them, and the
+	// caller redraws them afterwards.
+	DrawSecondarySel(p0, p1 int, on bool)
 }
 
 // TODO(rjk): Consider calling this SetMaxtab?
@@ -40,3 +40,4 @@ func (mf *MockFrame) SelectOpt(*draw.Mousectl, *draw.Mouse, func(frame.SelectScr
 	return 0, 0
 }
 func (mf *MockFrame) DrawSel(image.Point, int, int, bool) {}
+func (mf *MockFrame) DrawSecondarySel(int, int, bool)     {}
@@ -78,6 +78,7 @@ var dirtabw = []*DirTab{
 	{"tag", plan9.QTAPPEND, QWtag, 0600 | plan9.DMAPPEND},
 	{"xdata", plan9.QTFILE, QWxdata, 0600},
 	{"spans", plan9.QTFILE, QWspans, 0200},
+	{"diagnostics", plan9.QTFILE, QWdiagnostics, 0600},
 }
 
 // windowDirTab returns the DirTab entry for window directory for the window with given id.
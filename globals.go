@@ -9,6 +9,7 @@ import (
 	"9fans.net/go/plumb"
 	"github.com/rjkroege/edwood/draw"
 	"github.com/rjkroege/edwood/frame"
+	"github.com/rjkroege/edwood/internal/theme"
 	"github.com/rjkroege/edwood/internal/ui"
 )
 
@@ -166,6 +167,11 @@ type globals struct {
 	// Used to resolve relative file paths. Persisted in dump files.
 	wdir string
 
+	// filehooks is the table of commands run automatically when a window
+	// is loaded with a matching file. Built from the user's hook file
+	// followed by defaultFileHooks; reloaded by the Hooks command.
+	filehooks []fileHook
+
 	// ═══════════════════════════════════════════════════════════════════
 	// Color Schemes
 	// ═══════════════════════════════════════════════════════════════════
@@ -182,6 +188,15 @@ type globals struct {
 	// yellow-green border, black text.
 	textcolors [frame.NumColours]draw.Image
 
+	// theme is the user's theme file. Its edwood.tag and edwood.body
+	// classes (and their .selection subclasses) override the default
+	// tagcolors and textcolors.
+	theme theme.Theme
+
+	// diagcolors holds the scrollbar marker color for each diagnostic
+	// Severity: red errors, orange warnings, blue info, grey hints.
+	diagcolors [numSeverities]draw.Image
+
 	// ═══════════════════════════════════════════════════════════════════
 	// Edit Command State
 	// ═══════════════════════════════════════════════════════════════════
@@ -242,8 +257,8 @@ type globals struct {
 	// Sends trigger warning display in the UI.
 	cwarn chan uint
 
-	// previewRenderCh receives render callbacks from preview debounce timers.
-	// The timer goroutine posts a closure that performs the deferred render;
+	// previewRenderCh receives render callbacks from preview debounce timers
+	// and the checks of followed files. The timer goroutine posts a closure that performs the deferred render;
 	// keyboardthread picks it up and executes it on the main goroutine.
 	previewRenderCh chan func()
 
@@ -293,6 +308,7 @@ func makeglobals() *globals {
 		cwarn:      make(chan uint),
 		mousestate:      ui.NewMouseState(),
 		previewRenderCh: make(chan func(), 1),
+		filehooks:       defaultFileHooks,
 	}
 
 	if home, err := os.UserHomeDir(); err == nil {
@@ -327,6 +343,26 @@ func makeglobals() *globals {
 	return g
 }
 
+// applyTheme replaces the colors in cols that the theme sets for class:
+// its fg and bg are the text and background colors, and those of
+// class.selection are the highlighted text and highlight colors.
+func (g *globals) applyTheme(display draw.Display, cols *[frame.NumColours]draw.Image, class string) {
+	set := func(col int, c string) {
+		if c != "" {
+			cols[col], _ = display.AllocImage(image.Rect(0, 0, 1, 1), display.ScreenImage().Pix(), true, draw.Color(theme.RGBA(c)))
+		}
+	}
+	if st, ok := g.theme[class]; ok {
+		set(frame.ColText, st.Fg)
+		set(frame.ColHText, st.Fg)
+		set(frame.ColBack, st.Bg)
+	}
+	if st, ok := g.theme[class+".selection"]; ok {
+		set(frame.ColHText, st.Fg)
+		set(frame.ColHigh, st.Bg)
+	}
+}
+
 // TODO(rjk): Can separate this out even better.
 func (g *globals) iconinit(display draw.Display) {
 	if g.tagcolors[frame.ColBack] == nil {
@@ -340,6 +376,8 @@ func (g *globals) iconinit(display draw.Display) {
 		g.textcolors[frame.ColBord], _ = display.AllocImage(image.Rect(0, 0, 1, 1), display.ScreenImage().Pix(), true, draw.Yellowgreen)
 		g.textcolors[frame.ColText] = display.Black()
 		g.textcolors[frame.ColHText] = display.Black()
+		g.applyTheme(display, &g.tagcolors, "edwood.tag")
+		g.applyTheme(display, &g.textcolors, "edwood.body")
 	}
 
 	// ...
@@ -363,4 +401,13 @@ func (g *globals) iconinit(display draw.Display) {
 
 	g.but2col, _ = display.AllocImage(image.Rect(0, 0, 1, 1), display.ScreenImage().Pix(), true, 0xAA0000FF)
 	g.but3col, _ = display.AllocImage(image.Rect(0, 0, 1, 1), display.ScreenImage().Pix(), true, 0x006600FF)
+
+	for sev, c := range [numSeverities]draw.Color{
+		SeverityError:   0xDD0000FF,
+		SeverityWarning: 0xEE8800FF,
+		SeverityInfo:    draw.Medblue,
+		SeverityHint:    0x888888FF,
+	} {
+		g.diagcolors[sev], _ = display.AllocImage(image.Rect(0, 0, 1, 1), display.ScreenImage().Pix(), true, c)
+	}
 }
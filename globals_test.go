@@ -0,0 +1,48 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rjkroege/edwood/draw"
+	"github.com/rjkroege/edwood/edwoodtest"
+	"github.com/rjkroege/edwood/frame"
+	"github.com/rjkroege/edwood/internal/theme"
+)
+
+func TestApplyTheme(t *testing.T) {
+	display := edwoodtest.NewDisplay(image.Rectangle{})
+	g := &globals{theme: theme.Theme{
+		"edwood.body":           {Fg: "#dddddd", Bg: "#1e1e1e"},
+		"edwood.body.selection": {Bg: "#44475a"},
+		"edwood.tag.selection":  {Fg: "#ffffff"},
+	}}
+	var body, tag [frame.NumColours]draw.Image
+	for i := range body {
+		body[i] = display.Black()
+		tag[i] = display.Black()
+	}
+	g.applyTheme(display, &body, "edwood.body")
+	g.applyTheme(display, &tag, "edwood.tag")
+
+	color := func(im draw.Image) string {
+		return im.(interface{ HtmlString() string }).HtmlString()
+	}
+	for _, tc := range []struct {
+		name string
+		im   draw.Image
+		want string
+	}{
+		{"body back", body[frame.ColBack], "#1e1e1e"},
+		{"body text", body[frame.ColText], "#dddddd"},
+		{"body high", body[frame.ColHigh], "#44475a"},
+		{"body htext", body[frame.ColHText], "#dddddd"},
+		{"body border", body[frame.ColBord], color(display.Black())},
+		{"tag back", tag[frame.ColBack], color(display.Black())},
+		{"tag htext", tag[frame.ColHText], "#ffffff"},
+	} {
+		if got := color(tc.im); got != tc.want {
+			t.Errorf("%s = %s; want %s", tc.name, got, tc.want)
+		}
+	}
+}
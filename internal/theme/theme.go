@@ -0,0 +1,154 @@
+// Package theme reads edwood's color theme file, which maps the names of
+// token classes to styles. It is shared by edwood, which takes its tag
+// and body colors from the theme, and by the span writers edcolor and
+// md2spans, which take their token colors from it.
+//
+// The theme file ($HOME/lib/edwood/theme) holds one class per line, with
+// colors and flags in the order of the spans protocol:
+//
+//	# class          fg        [bg]      [bold] [italic]
+//	keyword          #0000cc             bold
+//	comment          #808080   -         italic
+//	markdown.link    #66aaff
+//	edwood.body      #dddddd   #1e1e1e
+//
+// A color is #rrggbb, or "-" for the default. Class names are dotted,
+// most general first; see Lookup.
+package theme
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Style is the appearance of a class. Empty colors mean the default.
+type Style struct {
+	Fg, Bg string // "#rrggbb" or ""
+	Bold   bool
+	Italic bool
+}
+
+// IsZero reports whether s changes nothing from the default.
+func (s Style) IsZero() bool {
+	return s == Style{}
+}
+
+// Theme maps class names to styles.
+type Theme map[string]Style
+
+// Path returns the location of the user's theme file under home.
+func Path(home string) string {
+	return filepath.Join(home, "lib", "edwood", "theme")
+}
+
+// DefaultPath returns the location of the current user's theme file.
+func DefaultPath() string {
+	home, _ := os.UserHomeDir()
+	return Path(home)
+}
+
+// Lookup returns the style for class. A class with no style of its own
+// takes that of its longest dotted prefix, so keyword.control falls
+// back to keyword. ok is false if no style applies.
+func (t Theme) Lookup(class string) (st Style, ok bool) {
+	for {
+		if st, ok := t[class]; ok {
+			return st, true
+		}
+		i := strings.LastIndexByte(class, '.')
+		if i < 0 {
+			return Style{}, false
+		}
+		class = class[:i]
+	}
+}
+
+// Overlay returns a copy of t with the styles of over added, replacing
+// those of t for the same class.
+func (t Theme) Overlay(over Theme) Theme {
+	u := make(Theme, len(t)+len(over))
+	for class, st := range t {
+		u[class] = st
+	}
+	for class, st := range over {
+		u[class] = st
+	}
+	return u
+}
+
+// Parse reads a theme from r. name is used to label errors.
+func Parse(r io.Reader, name string) (Theme, error) {
+	t := make(Theme)
+	sc := bufio.NewScanner(r)
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: want class and color", name, lineno)
+		}
+		var st Style
+		colors := []*string{&st.Fg, &st.Bg}
+		for i, f := range fields[1:] {
+			switch {
+			case i < len(colors) && (f == "-" || strings.HasPrefix(f, "#")):
+				if f == "-" {
+					continue
+				}
+				if !IsColor(f) {
+					return nil, fmt.Errorf("%s:%d: bad color %q", name, lineno, f)
+				}
+				*colors[i] = f
+			case i > 0 && f == "bold":
+				st.Bold = true
+				colors = nil
+			case i > 0 && f == "italic":
+				st.Italic = true
+				colors = nil
+			default:
+				return nil, fmt.Errorf("%s:%d: bad field %q", name, lineno, f)
+			}
+		}
+		t[fields[0]] = st
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return t, nil
+}
+
+// Load reads the theme file at path. A missing file is an empty theme.
+func Load(path string) (Theme, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return Theme{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, path)
+}
+
+// IsColor reports whether s has the form #rrggbb.
+func IsColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 16, 32)
+	return err == nil
+}
+
+// RGBA returns the color s, which must satisfy IsColor, as 0xRRGGBBAA
+// with full opacity.
+func RGBA(s string) uint32 {
+	v, _ := strconv.ParseUint(s[1:], 16, 32)
+	return uint32(v)<<8 | 0xFF
+}
@@ -0,0 +1,131 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	src := `# comment
+
+keyword          #0000cc             bold
+comment          #808080   -         italic
+edwood.body      #dddddd   #1e1e1e
+highlight        -         #f0f4ff
+string           -
+`
+	got, err := Parse(strings.NewReader(src), "theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{
+		"keyword":     {Fg: "#0000cc", Bold: true},
+		"comment":     {Fg: "#808080", Italic: true},
+		"edwood.body": {Fg: "#dddddd", Bg: "#1e1e1e"},
+		"highlight":   {Bg: "#f0f4ff"},
+		"string":      {},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Parse mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src, err string
+	}{
+		{"keyword", "theme:1: want class and color"},
+		{"\nkeyword #00c", `theme:2: bad color "#00c"`},
+		{"keyword bold", `theme:1: bad field "bold"`},
+		{"keyword #0000cc bold #ffffff", `theme:1: bad field "#ffffff"`},
+		{"keyword #0000cc #ffffff #000000", `theme:1: bad field "#000000"`},
+		{"keyword #0000cc underline", `theme:1: bad field "underline"`},
+	} {
+		_, err := Parse(strings.NewReader(tc.src), "theme")
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("Parse(%q) error = %v; want %q", tc.src, err, tc.err)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	th := Theme{
+		"keyword":         {Fg: "#0000cc"},
+		"keyword.control": {Fg: "#cc0000"},
+	}
+	for _, tc := range []struct {
+		class string
+		want  Style
+		ok    bool
+	}{
+		{"keyword", Style{Fg: "#0000cc"}, true},
+		{"keyword.control", Style{Fg: "#cc0000"}, true},
+		{"keyword.control.flow", Style{Fg: "#cc0000"}, true},
+		{"keyword.other", Style{Fg: "#0000cc"}, true},
+		{"keywords", Style{}, false},
+		{"string", Style{}, false},
+	} {
+		got, ok := th.Lookup(tc.class)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Lookup(%q) = %v, %v; want %v, %v", tc.class, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	base := Theme{"keyword": {Fg: "#0000cc", Bold: true}, "string": {Fg: "#008000"}}
+	got := base.Overlay(Theme{"keyword": {Fg: "#ff0000"}, "comment": {Italic: true}})
+	want := Theme{
+		"keyword": {Fg: "#ff0000"},
+		"string":  {Fg: "#008000"},
+		"comment": {Italic: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Overlay mismatch (-want +got):\n%s", diff)
+	}
+	if base["keyword"].Fg != "#0000cc" {
+		t.Errorf("Overlay modified its receiver")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	th, err := Load(filepath.Join(dir, "missing"))
+	if err != nil || len(th) != 0 {
+		t.Errorf("Load of missing file = %v, %v; want empty theme", th, err)
+	}
+
+	p := filepath.Join(dir, "theme")
+	if err := os.WriteFile(p, []byte("string #008000\nbad\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(p)
+	if want := p + ":2: want class and color"; err == nil || err.Error() != want {
+		t.Errorf("Load error = %v; want %q", err, want)
+	}
+}
+
+func TestColor(t *testing.T) {
+	for _, tc := range []struct {
+		s  string
+		ok bool
+	}{
+		{"#1e1e1e", true},
+		{"#FFFFFF", true},
+		{"#fff", false},
+		{"1e1e1e0", false},
+		{"#1e1e1g", false},
+		{"-", false},
+	} {
+		if got := IsColor(tc.s); got != tc.ok {
+			t.Errorf("IsColor(%q) = %v; want %v", tc.s, got, tc.ok)
+		}
+	}
+	if got, want := RGBA("#1e2f3a"), uint32(0x1e2f3aff); got != want {
+		t.Errorf("RGBA = %#x; want %#x", got, want)
+	}
+}
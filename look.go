@@ -6,14 +6,13 @@ import (
 	"image"
 	"os"
 	"path/filepath"
-	"regexp"
 	"runtime/debug"
 	"strings"
 
 	"9fans.net/go/plan9"
 	"9fans.net/go/plan9/client"
 	"9fans.net/go/plumb"
-	"github.com/rjkroege/edwood/file"
+	"github.com/rjkroege/edwood/regexp"
 	"github.com/rjkroege/edwood/util"
 )
 
@@ -74,6 +73,9 @@ func look3(t *Text, q0 int, q1 int, external bool) {
 	if ct == nil {
 		global.seltext = t
 	}
+	if !external && t.w != nil && t == &t.w.body {
+		t.w.showDiagnostics(q0, q1, true)
+	}
 	e, expanded := expand(t, q0, q1)
 	if !external && t.w != nil && t.w.nopen[QWevent] > 0 {
 		// send alphanumeric expansion to external client
@@ -279,35 +281,33 @@ func plumbshow(m *plumb.Message) {
 }
 
 func search(ct *Text, r []rune) bool {
+	return searchflags(ct, r, 0)
+}
+
+// searchflags is like search but matches as flags say, such as only
+// whole words.
+func searchflags(ct *Text, r []rune, flags regexp.Flags) bool {
 	n := len(r)
 	if n > RBUFSIZE {
 		warning(nil, "string too long\n")
 		return false
 	}
 
-	res := regexp.QuoteMeta(string(r))
-	// Unless QuoteMeta has a bug, this will always work.
-	regexp := regexp.MustCompile(res)
-
-	start := ct.file.RuneTuple(ct.q1)
-	end := ct.file.End()
-	cursor := ct.file.MakeBufferCursor(start, end)
+	re, err := rxcompileflags(regexp.QuoteMeta(string(r)), flags)
+	if err != nil {
+		// Unless QuoteMeta has a bug, this can't happen.
+		return false
+	}
 
-	loc := regexp.FindReaderIndex(cursor)
-	if loc == nil {
+	sels := re.rxexecute(ct, nil, ct.q1, -1, 1)
+	if len(sels) == 0 {
 		// Try wrapped around.
-		cursor = ct.file.MakeBufferCursor(file.Ot(0, 0), start)
-		start = file.Ot(0, 0)
-		loc = regexp.FindReaderIndex(cursor)
+		sels = re.rxexecute(ct, nil, 0, ct.q1, 1)
 	}
-
-	if loc == nil {
+	if len(sels) == 0 {
 		return false
 	}
-
-	// loc is w.r.t. start right?
-	q1 := ct.file.ByteTuple(start.B + loc[1]).R
-	q0 := ct.file.ByteTuple(start.B + loc[0]).R
+	q0, q1 := sels[0][0].q0, sels[0][0].q1
 
 	if ct.w != nil {
 		ct.Show(q0, q1, true)
@@ -320,6 +320,30 @@ func search(ct *Text, r []rune) bool {
 
 }
 
+// lookflags removes Look's options from the start of arg: -i to match
+// without regard to case and -w to match only whole words. Options may
+// be combined, as in -iw.
+func lookflags(arg string) (regexp.Flags, string) {
+	var flags regexp.Flags
+	for {
+		words := wsre.Split(arg, 2)
+		opt, ok := strings.CutPrefix(words[0], "-")
+		if !ok || opt == "" || strings.Trim(opt, "iw") != "" {
+			return flags, arg
+		}
+		if strings.Contains(opt, "i") {
+			flags |= regexp.FoldCase
+		}
+		if strings.Contains(opt, "w") {
+			flags |= regexp.WholeWord
+		}
+		arg = ""
+		if len(words) > 1 {
+			arg = strings.TrimLeft(words[1], " \t\n")
+		}
+	}
+}
+
 func isfilespace(r rune) bool {
 	Lx := " \t"
 	return strings.ContainsRune(Lx, r)
@@ -454,6 +478,7 @@ func openfile(t *Text, e *Expand) *Window {
 		w.SetName(e.name)
 		t.Load(0, e.name, true)
 		t.file.Clean()
+		restoreUndoJournal(t)
 		t.w.tag.SetSelect(t.w.tag.file.Nr(), t.w.tag.file.Nr())
 		if ow != nil {
 			for _, inc := range ow.incl {
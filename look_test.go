@@ -5,12 +5,14 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"9fans.net/go/plumb"
 	"github.com/google/go-cmp/cmp"
 	"github.com/rjkroege/edwood/dumpfile"
 	"github.com/rjkroege/edwood/file"
+	"github.com/rjkroege/edwood/regexp"
 	"github.com/rjkroege/edwood/runes"
 )
 
@@ -298,3 +300,71 @@ func BenchmarkLook3(t *testing.B) {
 		})
 	}
 }
+
+func TestLookFlags(t *testing.T) {
+	for _, tc := range []struct {
+		arg   string
+		flags regexp.Flags
+		rest  string
+	}{
+		{"foo", 0, "foo"},
+		{"-i foo bar", regexp.FoldCase, "foo bar"},
+		{"-w\tfoo", regexp.WholeWord, "foo"},
+		{"-i -w foo", regexp.FoldCase | regexp.WholeWord, "foo"},
+		{"-iw", regexp.FoldCase | regexp.WholeWord, ""},
+		{"-x foo", 0, "-x foo"},
+		{"- foo", 0, "- foo"},
+	} {
+		flags, rest := lookflags(tc.arg)
+		if flags != tc.flags || rest != tc.rest {
+			t.Errorf("lookflags(%q) gave %v, %q; want %v, %q", tc.arg, flags, rest, tc.flags, tc.rest)
+		}
+	}
+}
+
+func TestSearchFlags(t *testing.T) {
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin("/home/gopher/a.txt"),
+		ScBody("/home/gopher/a.txt", "Foo food foo FOO"),
+	)
+	w := global.row.col[0].w[0]
+	for _, tc := range []struct {
+		flags regexp.Flags
+		want  []Range
+	}{
+		{0, []Range{{4, 7}, {9, 12}, {4, 7}}},
+		{regexp.WholeWord, []Range{{9, 12}, {9, 12}}},
+		{regexp.FoldCase | regexp.WholeWord, []Range{{0, 3}, {9, 12}, {13, 16}, {0, 3}}},
+	} {
+		w.body.q0, w.body.q1 = 0, 0
+		for _, want := range tc.want {
+			if !searchflags(&w.body, []rune("foo"), tc.flags) {
+				t.Fatalf("flags %v: no match", tc.flags)
+			}
+			if got := (Range{w.body.q0, w.body.q1}); got != want {
+				t.Errorf("flags %v: found %v; want %v", tc.flags, got, want)
+			}
+		}
+	}
+}
+
+func TestSearchLongFile(t *testing.T) {
+	// The file is searched a chunk at a time. The match crosses chunks.
+	body := strings.Repeat("x", 10000) + "needle" + strings.Repeat("y", 10000)
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin("/home/gopher/long.txt"),
+		ScBody("/home/gopher/long.txt", body),
+	)
+	w := global.row.col[0].w[0]
+	for _, q := range []int{0, 10006, 15000} {
+		w.body.q0, w.body.q1 = q, q
+		if !search(&w.body, []rune("needle")) {
+			t.Fatalf("from %d: no match", q)
+		}
+		if got, want := (Range{w.body.q0, w.body.q1}), (Range{10000, 10006}); got != want {
+			t.Errorf("from %d: found %v; want %v", q, got, want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"slices"
+
+	"github.com/rjkroege/edwood/draw"
+	"github.com/rjkroege/edwood/sam"
+)
+
+// Multiple selections.
+//
+// A body may have several selections. The primary selection is t.q0,
+// t.q1, which everything that acts on "the selection" uses; the others
+// are t.sels. Typing an edit key applies it at every selection through
+// one sam.Elog, so the change undoes as a unit. Keys that move the
+// selection, and a plain B1 click, drop the secondary selections.
+//
+// The 3-1 chord adds a selection: sweeping with B3 and pressing B1
+// before releasing it makes the sweep the primary selection, keeping
+// the old one. The Edit command k does the same for dot, so ,x/re/ k
+// selects every match. rdsel reads the selections separated by NUL
+// bytes, and text written to wrsel replaces them; see
+// replaceSelections.
+
+// selEdit replaces the runes q0 to q1 with r.
+type selEdit struct {
+	q0, q1 int
+	r      []rune
+}
+
+func rangeLess(a, b Range) bool {
+	return a.q0 < b.q0 || a.q0 == b.q0 && a.q1 < b.q1
+}
+
+func rangeCompare(a, b Range) int {
+	switch {
+	case rangeLess(a, b):
+		return -1
+	case rangeLess(b, a):
+		return 1
+	}
+	return 0
+}
+
+// overlaps reports whether a and b share a rune, are the same point, or
+// one is a point inside the other.
+func (a Range) overlaps(b Range) bool {
+	return a == b || a.q0 < b.q1 && b.q0 < a.q1
+}
+
+// normalizeSels returns others sorted, without the ranges that overlap
+// primary, and with overlapping ranges merged.
+func normalizeSels(primary Range, others []Range) []Range {
+	sorted := slices.Clone(others)
+	slices.SortFunc(sorted, rangeCompare)
+	var rs []Range
+	for _, r := range sorted {
+		if r.overlaps(primary) {
+			continue
+		}
+		if n := len(rs); n > 0 && rs[n-1].overlaps(r) {
+			rs[n-1].q1 = max(rs[n-1].q1, r.q1)
+			continue
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// selections returns all of t's selections in order, and the index of
+// the primary selection among them.
+func (t *Text) selections() ([]Range, int) {
+	primary := Range{t.q0, t.q1}
+	i, _ := slices.BinarySearchFunc(t.sels, primary, rangeCompare)
+	rs := make([]Range, 0, len(t.sels)+1)
+	rs = append(rs, t.sels[:i]...)
+	rs = append(rs, primary)
+	return append(rs, t.sels[i:]...), i
+}
+
+// setSelections makes q0, q1 the primary selection and others the
+// secondary ones. Only a body has secondary selections.
+func (t *Text) setSelections(q0, q1 int, others []Range) {
+	t.sels = nil
+	if t.what == Body {
+		t.sels = normalizeSels(Range{q0, q1}, others)
+	}
+	t.SetSelect(q0, q1)
+}
+
+// addSelection makes q0, q1 the primary selection, keeping the old
+// primary selection as a secondary one.
+func (t *Text) addSelection(q0, q1 int) {
+	t.setSelections(q0, q1, append(slices.Clone(t.sels), Range{t.q0, t.q1}))
+}
+
+// clearSelections drops the secondary selections.
+func (t *Text) clearSelections() {
+	if len(t.sels) > 0 {
+		t.sels = nil
+		t.SetSelect(t.q0, t.q1)
+	}
+}
+
+// drawSels paints the secondary selections visible in the frame.
+func (t *Text) drawSels() {
+	nchars := t.fr.GetFrameFillStatus().Nchars
+	for _, s := range t.sels {
+		p0, p1 := s.q0-t.org, s.q1-t.org
+		if p1 < 0 {
+			continue
+		}
+		if p0 > nchars {
+			break
+		}
+		p0, p1 = max(p0, 0), min(p1, nchars)
+		t.fr.DrawSecondarySel(p0, p1, true)
+		t.drawnsels = append(t.drawnsels, Range{p0, p1})
+	}
+}
+
+// undrawSels repaints as plain text where drawSels last painted.
+func (t *Text) undrawSels() {
+	for _, s := range t.drawnsels {
+		t.fr.DrawSecondarySel(s.q0, s.q1, false)
+	}
+	t.drawnsels = t.drawnsels[:0]
+}
+
+// applySelEdits makes edits, which are in order and do not overlap, as
+// one change. Edit i comes from selection i, and primary is the index
+// of the primary selection. Afterwards each selection covers its new
+// text if selectText is set, and is the point after it otherwise.
+func (t *Text) applySelEdits(edits []selEdit, primary int, selectText bool) {
+	e := sam.MakeElog()
+	for _, ed := range edits {
+		// The edits are in order, so the log cannot complain.
+		_ = e.Replace(ed.q0, ed.q1, ed.r)
+	}
+	// Don't redraw the secondary selections at the intermediate
+	// positions the log passes through.
+	t.sels = nil
+	e.Apply(t)
+
+	rs := make([]Range, len(edits))
+	delta := 0
+	for i, ed := range edits {
+		q0 := ed.q0 + delta
+		q1 := q0 + len(ed.r)
+		delta += len(ed.r) - (ed.q1 - ed.q0)
+		if !selectText {
+			q0 = q1
+		}
+		rs[i] = Range{q0, q1}
+	}
+	p := rs[primary]
+	t.sels = normalizeSels(p, slices.Delete(rs, primary, primary+1))
+	t.Show(p.q0, p.q1, true)
+}
+
+// typeSelections types r at every selection of a body with secondary
+// selections. It returns false if r is left to Type: keys that scroll,
+// copy or undo keep the selections, and other keys that do not edit
+// drop the secondary ones first.
+func (t *Text) typeSelections(r rune) bool {
+	switch r {
+	case draw.KeyUp, draw.KeyDown, 0xF800, draw.KeyPageUp, draw.KeyPageDown,
+		Kscrolloneup, Kscrollonedown, 0x03, 0x1a,
+		draw.KeyCmd + 'c', draw.KeyCmd + 'z', draw.KeyCmd + 'Z':
+		return false
+	case '\t':
+		if t.tabexpand {
+			return false // Type types the spaces
+		}
+	case 0x08, 0x15, 0x17, 0x7F, '\n':
+	case 0x01, 0x05, 0x06, 0x16, 0x18, 0x1B:
+		t.clearSelections()
+		return false
+	default:
+		if r >= KF {
+			t.clearSelections()
+			return false
+		}
+	}
+
+	erase := r == 0x08 || r == 0x15 || r == 0x17 || r == 0x7F
+	mark := erase || r == '\n' || t.eq0 == -1
+
+	rs, primary := t.selections()
+	edits := make([]selEdit, len(rs))
+	prev := 0 // end of the previous selection
+	for i, s := range rs {
+		ed := selEdit{q0: s.q0, q1: s.q1}
+		switch {
+		case erase && r != 0x7F:
+			if s.q0 == s.q1 {
+				ed.q0 = max(s.q0-t.bsWidthAt(s.q0, r), prev)
+			}
+		case r == 0x7F:
+			if s.q0 == s.q1 && s.q0 < t.Nc() && (i+1 == len(rs) || rs[i+1].q0 > s.q0) {
+				ed.q1 = s.q0 + 1
+			}
+		case r == '\n' && t.w != nil && t.w.autoindent:
+			ed.r = append([]rune{'\n'}, t.indentAt(s.q0)...)
+		default:
+			ed.r = []rune{r}
+		}
+		edits[i] = ed
+		prev = s.q1
+		mark = mark || s.q0 != s.q1
+	}
+
+	if mark {
+		global.seq++
+		t.file.Mark(global.seq)
+	}
+	t.applySelEdits(edits, primary, false)
+	if erase || r == '\n' {
+		t.TypeCommit()
+	}
+	t.iq1 = t.q0
+	return true
+}
+
+// indentAt returns the leading blanks of the line holding q.
+func (t *Text) indentAt(q int) []rune {
+	nnb := t.bsWidthAt(q, 0x15)
+	var indent []rune
+	for i := 0; i < nnb; i++ {
+		r := t.file.ReadC(q - nnb + i)
+		if r != ' ' && r != '\t' {
+			break
+		}
+		indent = append(indent, r)
+	}
+	return indent
+}
+
+// replaceSelections replaces the selections with text. If text has one
+// NUL-separated piece per selection, each selection gets its own piece;
+// otherwise each gets all of text. The selections then cover the new
+// text.
+func (t *Text) replaceSelections(text []rune) {
+	rs, primary := t.selections()
+	pieces := splitRunes(text, 0)
+	edits := make([]selEdit, len(rs))
+	for i, s := range rs {
+		ed := selEdit{s.q0, s.q1, text}
+		if len(pieces) == len(rs) {
+			ed.r = pieces[i]
+		}
+		edits[i] = ed
+	}
+	t.applySelEdits(edits, primary, true)
+}
+
+// selectionsReader returns a reader of the text of the selections,
+// separated by NUL bytes.
+func (t *Text) selectionsReader() io.Reader {
+	rs, _ := t.selections()
+	readers := make([]io.Reader, 0, 2*len(rs))
+	for i, s := range rs {
+		if i > 0 {
+			readers = append(readers, bytes.NewReader([]byte{0}))
+		}
+		readers = append(readers, t.file.Reader(s.q0, s.q1))
+	}
+	return io.MultiReader(readers...)
+}
+
+// splitRunes splits r at each sep.
+func splitRunes(r []rune, sep rune) [][]rune {
+	var pieces [][]rune
+	for {
+		i := slices.Index(r, sep)
+		if i < 0 {
+			return append(pieces, r)
+		}
+		pieces = append(pieces, r[:i])
+		r = r[i+1:]
+	}
+}
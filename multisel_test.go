@@ -0,0 +1,242 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rjkroege/edwood/dumpfile"
+)
+
+func TestNormalizeSels(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		primary Range
+		others  []Range
+		want    []Range
+	}{
+		{"Sorted", Range{20, 20}, []Range{{8, 9}, {2, 4}, {0, 0}}, []Range{{0, 0}, {2, 4}, {8, 9}}},
+		{"DropPrimary", Range{3, 6}, []Range{{0, 1}, {5, 8}, {3, 3}, {6, 6}}, []Range{{0, 1}, {3, 3}, {6, 6}}},
+		{"DropSamePoint", Range{4, 4}, []Range{{4, 4}, {1, 1}}, []Range{{1, 1}}},
+		{"Merge", Range{20, 20}, []Range{{0, 4}, {2, 6}, {6, 8}, {10, 10}, {10, 10}}, []Range{{0, 6}, {6, 8}, {10, 10}}},
+		{"PointInside", Range{20, 20}, []Range{{0, 4}, {2, 2}}, []Range{{0, 4}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := normalizeSels(tc.primary, tc.others)
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(Range{})); diff != "" {
+				t.Errorf("normalizeSels mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func makeMultiselWindow(body string) *Window {
+	MakeWindowScaffold(&dumpfile.Content{
+		Columns: []dumpfile.Column{
+			{},
+		},
+		Windows: []*dumpfile.Window{
+			{
+				Column: 0,
+				Tag:    dumpfile.Text{Buffer: ""},
+				Body:   dumpfile.Text{Buffer: body},
+			},
+		},
+	})
+	return global.row.col[0].w[0]
+}
+
+func TestTypeSelections(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		body     string
+		sels     []Range // the last is primary
+		input    string
+		want     string
+		wantSels []Range
+		wantQ    Range
+	}{
+		{"Insert", "ab\nab\nab\n", []Range{{0, 0}, {6, 6}, {3, 3}}, "xy", "xyab\nxyab\nxyab\n", []Range{{2, 2}, {12, 12}}, Range{7, 7}},
+		{"Replace", "foo bar foo", []Range{{0, 3}, {8, 11}}, "z", "z bar z", []Range{{1, 1}}, Range{7, 7}},
+		{"Backspace", "ab\nab\n", []Range{{2, 2}, {5, 5}}, "\b", "a\na\n", []Range{{1, 1}}, Range{3, 3}},
+		{"BackspaceAtStart", "ab", []Range{{0, 0}, {1, 1}}, "\b", "b", nil, Range{0, 0}},
+		{"EraseWord", "one two\nthree four", []Range{{7, 7}, {18, 18}}, "\x17", "one \nthree ", []Range{{4, 4}}, Range{11, 11}},
+		{"Delete", "abc\nabc", []Range{{0, 0}, {4, 4}}, "\x7f", "bc\nbc", []Range{{0, 0}}, Range{3, 3}},
+		{"Newline", "\tx\n\ty", []Range{{2, 2}, {5, 5}}, "\n", "\tx\n\t\n\ty\n\t", []Range{{4, 4}}, Range{9, 9}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := makeMultiselWindow(tc.body)
+			w.autoindent = true
+			body := &w.body
+			p := tc.sels[len(tc.sels)-1]
+			body.setSelections(p.q0, p.q1, tc.sels[:len(tc.sels)-1])
+			body.eq0 = -1 // as after a click
+
+			for _, r := range tc.input {
+				body.Type(r)
+			}
+			if got := body.file.String(); got != tc.want {
+				t.Errorf("body is %q; want %q", got, tc.want)
+			}
+			if diff := cmp.Diff(tc.wantSels, body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+				t.Errorf("secondary selections mismatch (-want +got):\n%s", diff)
+			}
+			if got := (Range{body.q0, body.q1}); got != tc.wantQ {
+				t.Errorf("primary selection is %v; want %v", got, tc.wantQ)
+			}
+
+			// The typing undoes as a unit.
+			w.Undo(true)
+			if got := body.file.String(); got != tc.body {
+				t.Errorf("after undo, body is %q; want %q", got, tc.body)
+			}
+		})
+	}
+}
+
+func TestTypeSelectionsMoveClears(t *testing.T) {
+	w := makeMultiselWindow("abc abc")
+	body := &w.body
+	body.setSelections(4, 4, []Range{{0, 0}})
+
+	body.Type(Kscrollonedown)
+	if len(body.sels) != 1 {
+		t.Fatalf("scrolling dropped the secondary selections")
+	}
+	body.Type(0x05) // ^E
+	if body.sels != nil {
+		t.Errorf("secondary selections %v remain after moving", body.sels)
+	}
+	body.Type('x')
+	if got, want := body.file.String(), "abc abcx"; got != want {
+		t.Errorf("body is %q; want %q", got, want)
+	}
+}
+
+func TestSelectionsFollowEdits(t *testing.T) {
+	w := makeMultiselWindow("0123456789")
+	body := &w.body
+	body.setSelections(8, 9, []Range{{2, 4}, {5, 5}})
+
+	body.file.InsertAt(0, []rune("ab"))
+	if diff := cmp.Diff([]Range{{4, 6}, {7, 7}}, body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+		t.Errorf("after insert (-want +got):\n%s", diff)
+	}
+	body.file.DeleteAt(5, 8)
+	if diff := cmp.Diff([]Range{{4, 5}, {5, 5}}, body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+		t.Errorf("after delete (-want +got):\n%s", diff)
+	}
+	if got, want := (Range{body.q0, body.q1}), (Range{7, 8}); got != want {
+		t.Errorf("primary selection is %v; want %v", got, want)
+	}
+}
+
+func TestAddSelection(t *testing.T) {
+	w := makeMultiselWindow("one two three")
+	body := &w.body
+	body.SetSelect(0, 3)
+	body.addSelection(8, 13)
+	body.addSelection(4, 7)
+	if diff := cmp.Diff([]Range{{0, 3}, {8, 13}}, body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+		t.Errorf("secondary selections mismatch (-want +got):\n%s", diff)
+	}
+	// Adding over an existing selection replaces it.
+	body.addSelection(9, 10)
+	if diff := cmp.Diff([]Range{{0, 3}, {4, 7}}, body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+		t.Errorf("after overlapping add (-want +got):\n%s", diff)
+	}
+
+	body.clearSelections()
+	if body.sels != nil {
+		t.Errorf("clearSelections left %v", body.sels)
+	}
+	if got, want := (Range{body.q0, body.q1}), (Range{9, 10}); got != want {
+		t.Errorf("primary selection is %v; want %v", got, want)
+	}
+}
+
+func TestEditKeep(t *testing.T) {
+	runfunc = mockrun
+	defer func() { runfunc = run }()
+	global.cedit = make(chan int)
+
+	for _, tc := range []struct {
+		expr     string
+		want     string
+		wantSels []Range
+		wantQ    Range
+	}{
+		{",x/is/ k", contents, []Range{{2, 4}}, Range{5, 7}},
+		{",x/^/ k", contents, []Range{{0, 0}, {10, 10}, {21, 21}}, Range{39, 39}},
+		{",x/t/ {\nk\nc/T/\n}", "This is a\nshorT TexT\nTo Try addressing\n", []Range{{14, 15}, {16, 17}, {19, 20}, {21, 22}}, Range{24, 25}},
+		{",x/is/ p", contents, nil, Range{5, 7}},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			warningsMu.Lock()
+			warnings = []*Warning{}
+			warningsMu.Unlock()
+
+			FlexiblyMakeWindowScaffold(
+				t,
+				ScWin("test"),
+				ScBody("test", contents),
+			)
+			w := global.row.col[0].w[0]
+
+			global.row.lk.Lock()
+			w.Lock('M')
+			editcmd(&w.body, []rune(tc.expr))
+			w.Unlock()
+			global.row.lk.Unlock()
+
+			if got := w.body.file.String(); got != tc.want {
+				t.Errorf("body is %q; want %q", got, tc.want)
+			}
+			if diff := cmp.Diff(tc.wantSels, w.body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+				t.Errorf("secondary selections mismatch (-want +got):\n%s", diff)
+			}
+			if got := (Range{w.body.q0, w.body.q1}); got != tc.wantQ {
+				t.Errorf("primary selection is %v; want %v", got, tc.wantQ)
+			}
+		})
+	}
+}
+
+func TestReplaceSelections(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		text     string
+		want     string
+		wantSels []Range
+		wantQ    Range
+	}{
+		{"Pieces", "A\x00BB\x00CCC", "A BB CCC", []Range{{0, 1}, {5, 8}}, Range{2, 4}},
+		{"Same", "x", "x x x", []Range{{0, 1}, {4, 5}}, Range{2, 3}},
+		{"WrongCount", "1\x002", "1\x002 1\x002 1\x002", []Range{{0, 3}, {8, 11}}, Range{4, 7}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := makeMultiselWindow("a bb ccc")
+			body := &w.body
+			body.setSelections(2, 4, []Range{{0, 1}, {5, 8}})
+
+			b, err := io.ReadAll(body.selectionsReader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(b), "a\x00bb\x00ccc"; got != want {
+				t.Errorf("selectionsReader read %q; want %q", got, want)
+			}
+
+			body.replaceSelections([]rune(tc.text))
+			if got := body.file.String(); got != tc.want {
+				t.Errorf("body is %q; want %q", got, tc.want)
+			}
+			if diff := cmp.Diff(tc.wantSels, body.sels, cmp.AllowUnexported(Range{})); diff != "" {
+				t.Errorf("secondary selections mismatch (-want +got):\n%s", diff)
+			}
+			if got := (Range{body.q0, body.q1}); got != tc.wantQ {
+				t.Errorf("primary selection is %v; want %v", got, tc.wantQ)
+			}
+		})
+	}
+}
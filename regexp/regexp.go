@@ -8,59 +8,53 @@
 // general syntax used by Perl, Python, and other languages.
 // More precisely, it is the syntax accepted by RE2 and described at
 // https://golang.org/s/re2syntax, except for \C.
-// For an overview of the syntax, run
-//
-//	go doc regexp/syntax
+// For an overview of the syntax, see the [regexp/syntax] package.
 //
 // The regexp implementation provided by this package is
 // guaranteed to run in time linear in the size of the input.
 // (This is a property not guaranteed by most open source
 // implementations of regular expressions.) For more information
-// about this property, see
-//
-//	https://swtch.com/~rsc/regexp/regexp1.html
-//
+// about this property, see https://swtch.com/~rsc/regexp/regexp1.html
 // or any book about automata theory.
 //
 // All characters are UTF-8-encoded code points.
+// Following [utf8.DecodeRune], each byte of an invalid UTF-8 sequence
+// is treated as if it encoded utf8.RuneError (U+FFFD).
 //
-// There are 16 methods of Regexp that match a regular expression and identify
+// There are 24 methods of [Regexp] that match a regular expression and identify
 // the matched text. Their names are matched by this regular expression:
 //
-//	Find(All)?(String)?(Submatch)?(Index)?
+//	(All|Find|FindAll)(String)?(Submatch)?(Index)?
 //
-// If 'All' is present, the routine matches successive non-overlapping
-// matches of the entire expression. Empty matches abutting a preceding
-// match are ignored. The return value is a slice containing the successive
-// return values of the corresponding non-'All' routine. These routines take
-// an extra integer argument, n. If n >= 0, the function returns at most n
-// matches/submatches; otherwise, it returns all of them.
+// The ‘All’ variants return an iterator over successive non-overlapping
+// matches of the entire expression. The ‘FindAll’ variants return a slice
+// of those matches instead. Empty matches abutting a preceding
+// match are ignored. The ‘FindAll’ variants take an extra integer argument, n.
+// If n >= 0, the function returns at most n matches/submatches;
+// otherwise, it returns all of them.
 //
-// If 'String' is present, the argument is a string; otherwise it is a slice
-// of bytes; return values are adjusted as appropriate.
-//
-// If 'Submatch' is present, the return value is a slice identifying the
-// successive submatches of the expression. Submatches are matches of
-// parenthesized subexpressions (also known as capturing groups) within the
-// regular expression, numbered from left to right in order of opening
-// parenthesis. Submatch 0 is the match of the entire expression, submatch 1
-// the match of the first parenthesized subexpression, and so on.
+// The ‘Find’ variants return only the first match that All or FindAll would return.
 //
-// If 'Index' is present, matches and submatches are identified by byte index
-// pairs within the input string: result[2*n:2*n+1] identifies the indexes of
-// the nth submatch. The pair for n==0 identifies the match of the entire
-// expression. If 'Index' is not present, the match is identified by the
-// text of the match/submatch. If an index is negative, it means that
-// subexpression did not match any string in the input.
+// If ‘String’ is present, the argument is a string; otherwise it is a []byte.
 //
-// There is also a subset of the methods that can be applied to text read
-// from a RuneReader:
-//
-//	MatchReader, FindReaderIndex, FindReaderSubmatchIndex
+// By default, each returned match is denoted by the substring matching the
+// regular expression, of type string or []byte according to the type of the argument.
+// If ‘Submatch’ is present, each match is represented instead by a slice of
+// the substrings matching the regular expression's parenthesized subexpressions
+// (also known as capturing groups), numbered from left to right in order of opening
+// parenthesis. Submatch 0 is the match of the entire expression, submatch 1 is
+// the match of the first parenthesized subexpression, and so on.
+// If ‘Index’ is present, each substring is instead denoted by a pair of byte indexes
+// within the input string. If an index is negative or substring is nil, it means that
+// the subexpression did not match any string in the input. For ‘String’ versions,
+// an empty string means either no match or an empty match.
 //
-// This set may grow. Note that regular expression matches may need to
+// There is also a subset of the methods that can be applied to text read from
+// an [io.RuneReader]: [Regexp.MatchReader], [Regexp.FindReaderIndex],
+// [Regexp.FindReaderSubmatchIndex].
+// Note that regular expression matches may need to
 // examine text beyond the text returned by a match, so the methods that
-// match text from a RuneReader may read arbitrarily far into the input
+// match text from an [io.RuneReader] may read arbitrarily far into the input
 // before returning.
 //
 // (There are a few other methods that do not match this pattern.)
@@ -69,7 +63,9 @@ package regexp
 import (
 	"bytes"
 	"io"
+	"iter"
 	"regexp/syntax"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -79,7 +75,7 @@ import (
 
 // Regexp is the representation of a compiled regular expression.
 // A Regexp is safe for concurrent use by multiple goroutines,
-// except for configuration methods, such as Longest.
+// except for configuration methods, such as [Regexp.Longest].
 type Regexp struct {
 	expr           string       // as passed to Compile
 	prog           *syntax.Prog // compiled program
@@ -95,6 +91,7 @@ type Regexp struct {
 	matchcap       int            // size of recorded match lengths
 	prefixComplete bool           // prefix is the entire regexp
 	cond           syntax.EmptyOp // empty-width conditions required at start of match
+	minInputLen    int            // minimum length of the input in bytes
 
 	// This field can be modified by the Longest method,
 	// but it is otherwise read-only.
@@ -106,21 +103,21 @@ func (re *Regexp) String() string {
 	return re.expr
 }
 
-// Copy returns a new Regexp object copied from re.
-// Calling Longest on one copy does not affect another.
+// Copy returns a new [Regexp] object copied from re.
+// Calling [Regexp.Longest] on one copy does not affect another.
 //
-// Deprecated: In earlier releases, when using a Regexp in multiple goroutines,
+// Deprecated: In earlier releases, when using a [Regexp] in multiple goroutines,
 // giving each goroutine its own copy helped to avoid lock contention.
 // As of Go 1.12, using Copy is no longer necessary to avoid lock contention.
 // Copy may still be appropriate if the reason for its use is to make
-// two copies with different Longest settings.
+// two copies with different [Regexp.Longest] settings.
 func (re *Regexp) Copy() *Regexp {
 	re2 := *re
 	return &re2
 }
 
 // Compile parses a regular expression and returns, if successful,
-// a Regexp object that can be used to match against text.
+// a [Regexp] object that can be used to match against text.
 //
 // When matching against text, the regexp returns a match that
 // begins as early as possible in the input (leftmost), and among those
@@ -128,12 +125,12 @@ func (re *Regexp) Copy() *Regexp {
 // This so-called leftmost-first matching is the same semantics
 // that Perl, Python, and other implementations use, although this
 // package implements it without the expense of backtracking.
-// For POSIX leftmost-longest matching, see CompilePOSIX.
+// For POSIX leftmost-longest matching, see [CompilePOSIX].
 func Compile(expr string) (*Regexp, error) {
 	return compile(expr, syntax.Perl, false)
 }
 
-// CompilePOSIX is like Compile but restricts the regular expression
+// CompilePOSIX is like [Compile] but restricts the regular expression
 // to POSIX ERE (egrep) syntax and changes the match semantics to
 // leftmost-longest.
 //
@@ -160,7 +157,7 @@ func CompilePOSIX(expr string) (*Regexp, error) {
 // That is, when matching against text, the regexp returns a match that
 // begins as early as possible in the input (leftmost), and among those
 // it chooses a match that is as long as possible.
-// This method modifies the Regexp and may not be called concurrently
+// This method modifies the [Regexp] and may not be called concurrently
 // with any other methods.
 func (re *Regexp) Longest() {
 	re.longest = true
@@ -192,6 +189,7 @@ func compile(expr string, mode syntax.Flags, longest bool) (*Regexp, error) {
 		cond:        prog.StartCond(),
 		longest:     longest,
 		matchcap:    matchcap,
+		minInputLen: minInputLen(re),
 	}
 	if regexp.onepass == nil {
 		regexp.prefix, regexp.prefixComplete = prog.Prefix()
@@ -216,7 +214,7 @@ func compile(expr string, mode syntax.Flags, longest bool) (*Regexp, error) {
 	return regexp, nil
 }
 
-// Pools of *machine for use during (*Regexp).doExecute,
+// Pools of *machine for use during (*Regexp).find,
 // split up by the size of the execution queues.
 // matchPool[i] machines have queue size matchSize[i].
 // On a 64-bit system each queue entry is 16 bytes,
@@ -265,7 +263,47 @@ func (re *Regexp) put(m *machine) {
 	matchPool[re.mpool].Put(m)
 }
 
-// MustCompile is like Compile but panics if the expression cannot be parsed.
+// minInputLen walks the regexp to find the minimum length of any matchable input.
+func minInputLen(re *syntax.Regexp) int {
+	switch re.Op {
+	default:
+		return 0
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpCharClass:
+		return 1
+	case syntax.OpLiteral:
+		l := 0
+		for _, r := range re.Rune {
+			if r == utf8.RuneError {
+				l++
+			} else {
+				l += utf8.RuneLen(r)
+			}
+		}
+		return l
+	case syntax.OpCapture, syntax.OpPlus:
+		return minInputLen(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * minInputLen(re.Sub[0])
+	case syntax.OpConcat:
+		l := 0
+		for _, sub := range re.Sub {
+			l += minInputLen(sub)
+		}
+		return l
+	case syntax.OpAlternate:
+		l := minInputLen(re.Sub[0])
+		var lnext int
+		for _, sub := range re.Sub[1:] {
+			lnext = minInputLen(sub)
+			if lnext < l {
+				l = lnext
+			}
+		}
+		return l
+	}
+}
+
+// MustCompile is like [Compile] but panics if the expression cannot be parsed.
 // It simplifies safe initialization of global variables holding compiled regular
 // expressions.
 func MustCompile(str string) *Regexp {
@@ -276,7 +314,7 @@ func MustCompile(str string) *Regexp {
 	return regexp
 }
 
-// MustCompilePOSIX is like CompilePOSIX but panics if the expression cannot be parsed.
+// MustCompilePOSIX is like [CompilePOSIX] but panics if the expression cannot be parsed.
 // It simplifies safe initialization of global variables holding compiled regular
 // expressions.
 func MustCompilePOSIX(str string) *Regexp {
@@ -294,13 +332,13 @@ func quote(s string) string {
 	return strconv.Quote(s)
 }
 
-// NumSubexp returns the number of parenthesized subexpressions in this Regexp.
+// NumSubexp returns the number of parenthesized subexpressions in this [Regexp].
 func (re *Regexp) NumSubexp() int {
 	return re.numSubexp
 }
 
 // SubexpNames returns the names of the parenthesized subexpressions
-// in this Regexp. The name for the first sub-expression is names[1],
+// in this [Regexp]. The name for the first sub-expression is names[1],
 // so that if m is a match slice, the name for m[i] is SubexpNames()[i].
 // Since the Regexp as a whole cannot be named, names[0] is always
 // the empty string. The slice should not be modified.
@@ -308,6 +346,24 @@ func (re *Regexp) SubexpNames() []string {
 	return re.subexpNames
 }
 
+// SubexpIndex returns the index of the first subexpression with the given name,
+// or -1 if there is no subexpression with that name.
+//
+// Note that multiple subexpressions can be written using the same name, as in
+// (?P<bob>a+)(?P<bob>b+), which declares two subexpressions named "bob".
+// In this case, SubexpIndex returns the index of the leftmost such subexpression
+// in the regular expression.
+func (re *Regexp) SubexpIndex(name string) int {
+	if name != "" {
+		for i, s := range re.subexpNames {
+			if name == s {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 const endOfText rune = -1
 
 // input abstracts different representations of the input text. It provides
@@ -327,10 +383,6 @@ type inputString struct {
 
 func (i *inputString) step(pos int) (rune, int) {
 	if pos < len(i.str) {
-		c := i.str[pos]
-		if c < utf8.RuneSelf {
-			return rune(c), 1
-		}
 		return utf8.DecodeRuneInString(i.str[pos:])
 	}
 	return endOfText, 0
@@ -352,17 +404,11 @@ func (i *inputString) context(pos int) lazyFlag {
 	r1, r2 := endOfText, endOfText
 	// 0 < pos && pos <= len(i.str)
 	if uint(pos-1) < uint(len(i.str)) {
-		r1 = rune(i.str[pos-1])
-		if r1 >= utf8.RuneSelf {
-			r1, _ = utf8.DecodeLastRuneInString(i.str[:pos])
-		}
+		r1, _ = utf8.DecodeLastRuneInString(i.str[:pos])
 	}
 	// 0 <= pos && pos < len(i.str)
 	if uint(pos) < uint(len(i.str)) {
-		r2 = rune(i.str[pos])
-		if r2 >= utf8.RuneSelf {
-			r2, _ = utf8.DecodeRuneInString(i.str[pos:])
-		}
+		r2, _ = utf8.DecodeRuneInString(i.str[pos:])
 	}
 	return newLazyFlag(r1, r2)
 }
@@ -374,10 +420,6 @@ type inputBytes struct {
 
 func (i *inputBytes) step(pos int) (rune, int) {
 	if pos < len(i.str) {
-		c := i.str[pos]
-		if c < utf8.RuneSelf {
-			return rune(c), 1
-		}
 		return utf8.DecodeRune(i.str[pos:])
 	}
 	return endOfText, 0
@@ -399,17 +441,11 @@ func (i *inputBytes) context(pos int) lazyFlag {
 	r1, r2 := endOfText, endOfText
 	// 0 < pos && pos <= len(i.str)
 	if uint(pos-1) < uint(len(i.str)) {
-		r1 = rune(i.str[pos-1])
-		if r1 >= utf8.RuneSelf {
-			r1, _ = utf8.DecodeLastRune(i.str[:pos])
-		}
+		r1, _ = utf8.DecodeLastRune(i.str[:pos])
 	}
 	// 0 <= pos && pos < len(i.str)
 	if uint(pos) < uint(len(i.str)) {
-		r2 = rune(i.str[pos])
-		if r2 >= utf8.RuneSelf {
-			r2, _ = utf8.DecodeRune(i.str[pos:])
-		}
+		r2, _ = utf8.DecodeRune(i.str[pos:])
 	}
 	return newLazyFlag(r1, r2)
 }
@@ -458,7 +494,7 @@ func (re *Regexp) LiteralPrefix() (prefix string, complete bool) {
 	return re.prefix, re.prefixComplete
 }
 
-// MatchReader reports whether the text returned by the RuneReader
+// MatchReader reports whether the text returned by the [io.RuneReader]
 // contains any match of the regular expression re.
 func (re *Regexp) MatchReader(r io.RuneReader) bool {
 	return re.doMatch(r, nil, "")
@@ -476,9 +512,9 @@ func (re *Regexp) Match(b []byte) bool {
 	return re.doMatch(nil, b, "")
 }
 
-// MatchReader reports whether the text returned by the RuneReader
+// MatchReader reports whether the text returned by the [io.RuneReader]
 // contains any match of the regular expression pattern.
-// More complicated queries need to use Compile and the full Regexp interface.
+// More complicated queries need to use [Compile] and the full [Regexp] interface.
 func MatchReader(pattern string, r io.RuneReader) (matched bool, err error) {
 	re, err := Compile(pattern)
 	if err != nil {
@@ -489,7 +525,7 @@ func MatchReader(pattern string, r io.RuneReader) (matched bool, err error) {
 
 // MatchString reports whether the string s
 // contains any match of the regular expression pattern.
-// More complicated queries need to use Compile and the full Regexp interface.
+// More complicated queries need to use [Compile] and the full [Regexp] interface.
 func MatchString(pattern string, s string) (matched bool, err error) {
 	re, err := Compile(pattern)
 	if err != nil {
@@ -500,7 +536,7 @@ func MatchString(pattern string, s string) (matched bool, err error) {
 
 // Match reports whether the byte slice b
 // contains any match of the regular expression pattern.
-// More complicated queries need to use Compile and the full Regexp interface.
+// More complicated queries need to use [Compile] and the full [Regexp] interface.
 func Match(pattern string, b []byte) (matched bool, err error) {
 	re, err := Compile(pattern)
 	if err != nil {
@@ -509,9 +545,9 @@ func Match(pattern string, b []byte) (matched bool, err error) {
 	return re.Match(b), nil
 }
 
-// ReplaceAllString returns a copy of src, replacing matches of the Regexp
-// with the replacement string repl. Inside repl, $ signs are interpreted as
-// in Expand, so for instance $1 represents the text of the first submatch.
+// ReplaceAllString returns a copy of src, replacing matches of the [Regexp]
+// with the replacement string repl.
+// Inside repl, $ signs are interpreted as in [Regexp.Expand].
 func (re *Regexp) ReplaceAllString(src, repl string) string {
 	n := 2
 	if strings.Contains(repl, "$") {
@@ -523,9 +559,9 @@ func (re *Regexp) ReplaceAllString(src, repl string) string {
 	return string(b)
 }
 
-// ReplaceAllLiteralString returns a copy of src, replacing matches of the Regexp
+// ReplaceAllLiteralString returns a copy of src, replacing matches of the [Regexp]
 // with the replacement string repl. The replacement repl is substituted directly,
-// without using Expand.
+// without using [Regexp.Expand].
 func (re *Regexp) ReplaceAllLiteralString(src, repl string) string {
 	return string(re.replaceAll(nil, src, 2, func(dst []byte, match []int) []byte {
 		return append(dst, repl...)
@@ -533,9 +569,9 @@ func (re *Regexp) ReplaceAllLiteralString(src, repl string) string {
 }
 
 // ReplaceAllStringFunc returns a copy of src in which all matches of the
-// Regexp have been replaced by the return value of function repl applied
+// [Regexp] have been replaced by the return value of function repl applied
 // to the matched substring. The replacement returned by repl is substituted
-// directly, without using Expand.
+// directly, without using [Regexp.Expand].
 func (re *Regexp) ReplaceAllStringFunc(src string, repl func(string) string) string {
 	b := re.replaceAll(nil, src, 2, func(dst []byte, match []int) []byte {
 		return append(dst, repl(src[match[0]:match[1]])...)
@@ -559,7 +595,7 @@ func (re *Regexp) replaceAll(bsrc []byte, src string, nmatch int, repl func(dst
 
 	var dstCap [2]int
 	for searchPos <= endPos {
-		a := re.doExecute(nil, bsrc, src, searchPos, nmatch, dstCap[:0])
+		a := re.find(nil, bsrc, src, searchPos, nmatch, dstCap[:0])
 		if len(a) == 0 {
 			break // no more matches
 		}
@@ -608,9 +644,9 @@ func (re *Regexp) replaceAll(bsrc []byte, src string, nmatch int, repl func(dst
 	return buf
 }
 
-// ReplaceAll returns a copy of src, replacing matches of the Regexp
-// with the replacement text repl. Inside repl, $ signs are interpreted as
-// in Expand, so for instance $1 represents the text of the first submatch.
+// ReplaceAll returns a copy of src, replacing matches of the [Regexp]
+// with the replacement text repl.
+// Inside repl, $ signs are interpreted as in [Regexp.Expand].
 func (re *Regexp) ReplaceAll(src, repl []byte) []byte {
 	n := 2
 	if bytes.IndexByte(repl, '$') >= 0 {
@@ -626,9 +662,9 @@ func (re *Regexp) ReplaceAll(src, repl []byte) []byte {
 	return b
 }
 
-// ReplaceAllLiteral returns a copy of src, replacing matches of the Regexp
+// ReplaceAllLiteral returns a copy of src, replacing matches of the [Regexp]
 // with the replacement bytes repl. The replacement repl is substituted directly,
-// without using Expand.
+// without using [Regexp.Expand].
 func (re *Regexp) ReplaceAllLiteral(src, repl []byte) []byte {
 	return re.replaceAll(src, "", 2, func(dst []byte, match []int) []byte {
 		return append(dst, repl...)
@@ -636,9 +672,9 @@ func (re *Regexp) ReplaceAllLiteral(src, repl []byte) []byte {
 }
 
 // ReplaceAllFunc returns a copy of src in which all matches of the
-// Regexp have been replaced by the return value of function repl applied
+// [Regexp] have been replaced by the return value of function repl applied
 // to the matched byte slice. The replacement returned by repl is substituted
-// directly, without using Expand.
+// directly, without using [Regexp.Expand].
 func (re *Regexp) ReplaceAllFunc(src []byte, repl func([]byte) []byte) []byte {
 	return re.replaceAll(src, "", 2, func(dst []byte, match []int) []byte {
 		return append(dst, repl(src[match[0]:match[1]])...)
@@ -706,141 +742,414 @@ func (re *Regexp) pad(a []int) []int {
 	return a
 }
 
-// allMatches calls deliver at most n times
-// with the location of successive matches in the input text.
+// matches yields the location of successive matches in the input text.
 // The input text is b if non-nil, otherwise s.
-func (re *Regexp) allMatches(s string, b []byte, n int, deliver func([]int)) {
-	var end int
-	if b == nil {
-		end = len(s)
-	} else {
-		end = len(b)
-	}
-
-	for pos, i, prevMatchEnd := 0, 0, -1; i < n && pos <= end; {
-		matches := re.doExecute(nil, b, s, pos, re.prog.NumCap, nil)
-		if len(matches) == 0 {
-			break
+func (re *Regexp) matches(s string, b []byte, max, ncap int) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		if max == 0 {
+			return
 		}
-
-		accept := true
-		if matches[1] == pos {
-			// We've found an empty match.
-			if matches[0] == prevMatchEnd {
-				// We don't allow an empty match right
-				// after a previous match, so ignore it.
-				accept = false
-			}
-			var width int
-			// TODO: use step()
-			if b == nil {
-				_, width = utf8.DecodeRuneInString(s[pos:end])
-			} else {
-				_, width = utf8.DecodeRune(b[pos:end])
+		var end int
+		if b == nil {
+			end = len(s)
+		} else {
+			end = len(b)
+		}
+		var matches []int
+		for pos, prevMatchEnd := 0, -1; pos <= end; {
+			matches = re.find(nil, b, s, pos, ncap, matches[:0])
+			if len(matches) == 0 {
+				break
 			}
-			if width > 0 {
-				pos += width
+
+			accept := true
+			if matches[1] == pos {
+				// We've found an empty match.
+				if matches[0] == prevMatchEnd {
+					// We don't allow an empty match right
+					// after a previous match, so ignore it.
+					accept = false
+				}
+				var width int
+				if b == nil {
+					is := inputString{str: s}
+					_, width = is.step(pos)
+				} else {
+					ib := inputBytes{str: b}
+					_, width = ib.step(pos)
+				}
+				if width > 0 {
+					pos += width
+				} else {
+					pos = end + 1
+				}
 			} else {
-				pos = end + 1
+				pos = matches[1]
 			}
-		} else {
-			pos = matches[1]
-		}
-		prevMatchEnd = matches[1]
+			prevMatchEnd = matches[1]
 
-		if accept {
-			deliver(re.pad(matches))
-			i++
+			if accept {
+				if !yield(re.pad(matches)) {
+					return
+				}
+				if max > 0 {
+					if max--; max == 0 {
+						return
+					}
+				}
+			}
 		}
 	}
 }
 
-// Find returns a slice holding the text of the leftmost match in b of the regular expression.
-// A return value of nil indicates no match.
+// Find returns the text of the leftmost match for re in b.
+// The return value is nil for no match.
 func (re *Regexp) Find(b []byte) []byte {
 	var dstCap [2]int
-	a := re.doExecute(nil, b, "", 0, 2, dstCap[:0])
-	if a == nil {
-		return nil
-	}
-	return b[a[0]:a[1]]
-}
-
-// FindIndex returns a two-element slice of integers defining the location of
-// the leftmost match in b of the regular expression. The match itself is at
-// b[loc[0]:loc[1]].
-// A return value of nil indicates no match.
-func (re *Regexp) FindIndex(b []byte) (loc []int) {
-	a := re.doExecute(nil, b, "", 0, 2, nil)
+	a := re.find(nil, b, "", 0, 2, dstCap[:0])
 	if a == nil {
 		return nil
 	}
-	return a[0:2]
+	return b[a[0]:a[1]:a[1]]
 }
 
-// FindString returns a string holding the text of the leftmost match in s of the regular
-// expression. If there is no match, the return value is an empty string,
-// but it will also be empty if the regular expression successfully matches
-// an empty string. Use FindStringIndex or FindStringSubmatch if it is
-// necessary to distinguish these cases.
+// FindString returns the text of the leftmost match for re in s.
+// The return value is the empty string both for an empty match and for no match.
+// To distinguish those two cases, use [Regexp.FindStringIndex] or [Regexp.FindStringSubmatch].
 func (re *Regexp) FindString(s string) string {
 	var dstCap [2]int
-	a := re.doExecute(nil, nil, s, 0, 2, dstCap[:0])
+	a := re.find(nil, nil, s, 0, 2, dstCap[:0])
 	if a == nil {
 		return ""
 	}
 	return s[a[0]:a[1]]
 }
 
-// FindStringIndex returns a two-element slice of integers defining the
-// location of the leftmost match in s of the regular expression. The match
-// itself is at s[loc[0]:loc[1]].
-// A return value of nil indicates no match.
-func (re *Regexp) FindStringIndex(s string) (loc []int) {
-	a := re.doExecute(nil, nil, s, 0, 2, nil)
-	if a == nil {
+// FindIndex returns the location of the leftmost match for re in b.
+// The match itself is at b[m[0]:m[1]].
+// The return value is nil for no match.
+func (re *Regexp) FindIndex(b []byte) (m []int) {
+	m = re.find(nil, b, "", 0, 2, nil)
+	if m == nil {
 		return nil
 	}
-	return a[0:2]
+	return m[0:2]
 }
 
-// FindReaderIndex returns a two-element slice of integers defining the
-// location of the leftmost match of the regular expression in text read from
-// the RuneReader. The match text was found in the input stream at
-// byte offset loc[0] through loc[1]-1.
-// A return value of nil indicates no match.
-func (re *Regexp) FindReaderIndex(r io.RuneReader) (loc []int) {
-	a := re.doExecute(r, nil, "", 0, 2, nil)
-	if a == nil {
+// FindStringIndex returns the location of the leftmost match for re in s.
+// The match itself is at s[m[0]:m[1]].
+// The return value is nil for no match.
+func (re *Regexp) FindStringIndex(s string) (m []int) {
+	m = re.find(nil, nil, s, 0, 2, nil)
+	if m == nil {
+		return nil
+	}
+	return m[0:2]
+}
+
+// FindReaderIndex returns the location of the leftmost match for re in r.
+// The match starts at byte index m[0] and ends just before byte index m[1].
+// The return value is nil for no match.
+//
+// FindReaderIndex may read arbitrarily far from r,
+// including reading beyond the returned match.
+func (re *Regexp) FindReaderIndex(r io.RuneReader) (m []int) {
+	m = re.find(r, nil, "", 0, 2, nil)
+	if m == nil {
 		return nil
 	}
-	return a[0:2]
+	return m[0:2]
 }
 
-// FindSubmatch returns a slice of slices holding the text of the leftmost
-// match of the regular expression in b and the matches, if any, of its
-// subexpressions, as defined by the 'Submatch' descriptions in the package
-// comment.
-// A return value of nil indicates no match.
+// FindSubmatch returns the first match for re in b, including submatches.
+// The overall match is m[0], the first submatch is m[1], and so on.
+// The return value is nil for no match.
 func (re *Regexp) FindSubmatch(b []byte) [][]byte {
 	var dstCap [4]int
-	a := re.doExecute(nil, b, "", 0, re.prog.NumCap, dstCap[:0])
+	m := re.find(nil, b, "", 0, re.prog.NumCap, dstCap[:0])
+	if m == nil {
+		return nil
+	}
+	sub := make([][]byte, 1+re.numSubexp)
+	for i := range sub {
+		if 2*i < len(m) && m[2*i] >= 0 {
+			sub[i] = b[m[2*i]:m[2*i+1]:m[2*i+1]]
+		}
+	}
+	return sub
+}
+
+// FindStringSubmatch returns the first match for re in s, including submatches.
+// The overall match is s[0], the first submatch is s[1], and so on.
+// The return value is nil for no match.
+func (re *Regexp) FindStringSubmatch(s string) []string {
+	var dstCap [4]int
+	a := re.find(nil, nil, s, 0, re.prog.NumCap, dstCap[:0])
 	if a == nil {
 		return nil
 	}
-	ret := make([][]byte, 1+re.numSubexp)
+	ret := make([]string, 1+re.numSubexp)
 	for i := range ret {
 		if 2*i < len(a) && a[2*i] >= 0 {
-			ret[i] = b[a[2*i]:a[2*i+1]]
+			ret[i] = s[a[2*i]:a[2*i+1]]
 		}
 	}
 	return ret
 }
 
+// FindSubmatchIndex returns the first match for re in b, including submatches.
+// The overall match is b[m[0]:m[1]], the first submatch is b[m[2]:m[3]], and so on.
+// The return value is nil for no match.
+func (re *Regexp) FindSubmatchIndex(b []byte) []int {
+	return re.pad(re.find(nil, b, "", 0, re.prog.NumCap, nil))
+}
+
+// FindStringSubmatchIndex returns the first match for re in s, including submatches.
+// The overall match is s[m[0]:m[1]], the first submatch is s[m[2]:m[3]], and so on.
+// The return value is nil for no match.
+func (re *Regexp) FindStringSubmatchIndex(s string) []int {
+	return re.pad(re.find(nil, nil, s, 0, re.prog.NumCap, nil))
+}
+
+// FindReaderSubmatchIndex returns the first match for re in r, including submatches.
+// The overall match is at byte index m[0] up to m[1],
+// the first submatch is at byte index m[2] up to m[3], and so on.
+// The return value is nil for no match.
+//
+// FindReaderSubmatchIndex may read arbitrarily far from r,
+// including reading beyond the returned match.
+func (re *Regexp) FindReaderSubmatchIndex(r io.RuneReader) []int {
+	return re.pad(re.find(r, nil, "", 0, re.prog.NumCap, nil))
+}
+
+// all returns at most n matches for re in b.
+func (re *Regexp) all(b []byte, n int) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for m := range re.matches("", b, n, 2) {
+			if !yield(b[m[0]:m[1]:m[1]]) {
+				break
+			}
+		}
+	}
+}
+
+// allString returns at most n matches for re in s.
+func (re *Regexp) allString(s string, n int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for m := range re.matches(s, nil, n, 2) {
+			if !yield(s[m[0]:m[1]]) {
+				break
+			}
+		}
+	}
+}
+
+// allIndex returns the locations of at most n matches for re in b.
+func (re *Regexp) allIndex(b []byte, n int) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		for m := range re.matches("", b, n, 2) {
+			if !yield([]int{m[0], m[1]}) {
+				break
+			}
+		}
+	}
+}
+
+// allStringIndex returns the locations of at most n matches for re in s.
+func (re *Regexp) allStringIndex(s string, n int) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		for m := range re.matches(s, nil, n, 2) {
+			if !yield([]int{m[0], m[1]}) {
+				break
+			}
+		}
+	}
+}
+
+// allSubmatch returns the locations of at most n matches for re in b,
+// including submatch locations.
+func (re *Regexp) allSubmatch(b []byte, n int) iter.Seq[[][]byte] {
+	return func(yield func([][]byte) bool) {
+		for m := range re.matches("", b, n, re.prog.NumCap) {
+			sub := make([][]byte, len(m)/2)
+			for i := range sub {
+				if m[2*i] >= 0 {
+					sub[i] = b[m[2*i]:m[2*i+1]:m[2*i+1]]
+				}
+			}
+			if !yield(sub) {
+				break
+			}
+		}
+	}
+}
+
+// allStringSubmatch returns the locations of at most n matches for re in s,
+// including submatch locations.
+func (re *Regexp) allStringSubmatch(s string, n int) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		for m := range re.matches(s, nil, n, re.prog.NumCap) {
+			sub := make([]string, len(m)/2)
+			for i := range sub {
+				if m[2*i] >= 0 {
+					sub[i] = s[m[2*i]:m[2*i+1]]
+				}
+			}
+			if !yield(sub) {
+				break
+			}
+		}
+	}
+}
+
+// allSubmatchIndex returns the locations of at most n matches for re in b,
+// including submatch locations.
+func (re *Regexp) allSubmatchIndex(b []byte, n int) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		for m := range re.matches("", b, n, re.prog.NumCap) {
+			if !yield(slices.Clone(m)) {
+				break
+			}
+		}
+	}
+}
+
+// allStringSubmatchIndex returns the locations of at most n matches for re in s,
+// including submatch locations.
+func (re *Regexp) allStringSubmatchIndex(s string, n int) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		for m := range re.matches(s, nil, n, re.prog.NumCap) {
+			if !yield(slices.Clone(m)) {
+				break
+			}
+		}
+	}
+}
+
+// All returns all the matches for re in b.
+func (re *Regexp) _All(b []byte) iter.Seq[[]byte] {
+	return re.all(b, -1)
+}
+
+// AllString returns all the matches for re in s.
+func (re *Regexp) _AllString(s string) iter.Seq[string] {
+	return re.allString(s, -1)
+}
+
+// AllIndex returns the locations of all matches for re in b.
+func (re *Regexp) _AllIndex(b []byte) iter.Seq[[]int] {
+	return re.allIndex(b, -1)
+}
+
+// AllStringIndex returns the locations of all matches for re in s.
+func (re *Regexp) _AllStringIndex(s string) iter.Seq[[]int] {
+	return re.allStringIndex(s, -1)
+}
+
+// AllSubmatch returns the locations of all matches for re in b,
+// including submatch locations.
+// In each returned match m, the overall match is m[0],
+// the first submatch is m[1], and so on.
+func (re *Regexp) _AllSubmatch(b []byte) iter.Seq[[][]byte] {
+	return re.allSubmatch(b, -1)
+}
+
+// AllStringSubmatch returns the locations of all matches for re in s,
+// including submatch locations.
+// In each returned match m, m[0] is the overall match,
+// m[1] is the first submatch, and so on.
+func (re *Regexp) _AllStringSubmatch(s string) iter.Seq[[]string] {
+	return re.allStringSubmatch(s, -1)
+}
+
+// AllSubmatchIndex returns the locations of all matches for re in b,
+// including submatch locations.
+// In each returned match m, the overall match is b[m[0]:m[1]],
+// the first submatch is b[m[2]:m[3]], and so on.
+func (re *Regexp) _AllSubmatchIndex(b []byte) iter.Seq[[]int] {
+	return re.allSubmatchIndex(b, -1)
+}
+
+// AllStringSubmatchIndex returns the locations of all matches for re in s,
+// including submatch locations.
+// In each returned match m, the overall match is s[m[0]:m[1]],
+// the first submatch is s[m[2]:m[3]], and so on.
+func (re *Regexp) _AllStringSubmatchIndex(s string) iter.Seq[[]int] {
+	return re.allStringSubmatchIndex(s, -1)
+}
+
+// FindAll returns all the matches for re in b.
+// If n >= 0, FindAll returns no more than n matches.
+// See [Regexp.All] for the equivalent iterator form.
+func (re *Regexp) FindAll(b []byte, n int) [][]byte {
+	return slices.Collect(re.all(b, n))
+}
+
+// FindAllString returns all the matches for re in s.
+// If n >= 0, FindAllString returns no more than n matches.
+// See [Regexp.AllString] for the equivalent iterator form.
+func (re *Regexp) FindAllString(s string, n int) []string {
+	return slices.Collect(re.allString(s, n))
+}
+
+// FindAllIndex returns the locations of all matches for re in b.
+// If n >= 0, FindAllIndex returns no more than n matches.
+// See [Regexp.AllIndex] for the equivalent iterator form.
+func (re *Regexp) FindAllIndex(b []byte, n int) [][]int {
+	return slices.Collect(re.allIndex(b, n))
+}
+
+// FindAllStringIndex returns the locations of all matches for re in s.
+// If n >= 0, FindAllStringIndex returns no more than n matches.
+// See [Regexp.AllStringIndex] for the equivalent iterator form.
+func (re *Regexp) FindAllStringIndex(s string, n int) [][]int {
+	return slices.Collect(re.allStringIndex(s, n))
+}
+
+// FindAllSubmatch returns the locations of all matches for re in b,
+// including submatch locations.
+// In each returned match m, the overall match is m[0],
+// the first submatch is m[1], and so on.
+// If n >= 0, FindAllSubmatch returns no more than n matches.
+// See [Regexp.AllSubmatch] for the equivalent iterator form.
+func (re *Regexp) FindAllSubmatch(b []byte, n int) [][][]byte {
+	return slices.Collect(re.allSubmatch(b, n))
+}
+
+// FindAllStringSubmatch returns the locations of all matches for re in s,
+// including submatch locations.
+// In each returned match m, m[0] is the overall match,
+// m[1] is the first submatch, and so on.
+// If n >= 0, FindAllStringSubmatch returns no more than n matches.
+// See [Regexp.AllStringSubmatch] for the equivalent iterator form.
+func (re *Regexp) FindAllStringSubmatch(s string, n int) [][]string {
+	return slices.Collect(re.allStringSubmatch(s, n))
+}
+
+// FindAllSubmatchIndex returns the locations of all matches for re in b,
+// including submatch locations.
+// In each returned match m, the overall match is b[m[0]:m[1]],
+// the first submatch is b[m[2]:m[3]], and so on.
+// If n >= 0, FindAllSubmatchIndex returns no more than n matches.
+// See [Regexp.AllSubmatchIndex] for the equivalent iterator form.
+func (re *Regexp) FindAllSubmatchIndex(b []byte, n int) [][]int {
+	return slices.Collect(re.allSubmatchIndex(b, n))
+}
+
+// FindAllStringSubmatchIndex returns the locations of all matches for re in s,
+// including submatch locations.
+// In each returned match m, the overall match is s[m[0]:m[1]],
+// the first submatch is s[m[2]:m[3]], and so on.
+// If n >= 0, FindAllStringSubmatchIndex returns no more than n matches.
+// See [Regexp.AllStringSubmatchIndex] for the equivalent iterator form.
+func (re *Regexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
+	return slices.Collect(re.allStringSubmatchIndex(s, n))
+}
+
 // Expand appends template to dst and returns the result; during the
 // append, Expand replaces variables in the template with corresponding
 // matches drawn from src. The match slice should have been returned by
-// FindSubmatchIndex.
+// [Regexp.FindSubmatchIndex].
 //
 // In the template, a variable is denoted by a substring of the form
 // $name or ${name}, where name is a non-empty sequence of letters,
@@ -858,7 +1167,7 @@ func (re *Regexp) Expand(dst []byte, template []byte, src []byte, match []int) [
 	return re.expand(dst, string(template), src, "", match)
 }
 
-// ExpandString is like Expand but the template and source are strings.
+// ExpandString is like [Regexp.Expand] but the template and source are strings.
 // It appends to and returns a byte slice in order to give the calling
 // code control over allocation.
 func (re *Regexp) ExpandString(dst []byte, template string, src string, match []int) []byte {
@@ -867,23 +1176,22 @@ func (re *Regexp) ExpandString(dst []byte, template string, src string, match []
 
 func (re *Regexp) expand(dst []byte, template string, bsrc []byte, src string, match []int) []byte {
 	for len(template) > 0 {
-		i := strings.Index(template, "$")
-		if i < 0 {
+		before, after, ok := strings.Cut(template, "$")
+		if !ok {
 			break
 		}
-		dst = append(dst, template[:i]...)
-		template = template[i:]
-		if len(template) > 1 && template[1] == '$' {
+		dst = append(dst, before...)
+		template = after
+		if template != "" && template[0] == '$' {
 			// Treat $$ as $.
 			dst = append(dst, '$')
-			template = template[2:]
+			template = template[1:]
 			continue
 		}
 		name, num, rest, ok := extract(template)
 		if !ok {
 			// Malformed; treat $ as raw text.
 			dst = append(dst, '$')
-			template = template[1:]
 			continue
 		}
 		template = rest
@@ -912,17 +1220,16 @@ func (re *Regexp) expand(dst []byte, template string, bsrc []byte, src string, m
 	return dst
 }
 
-// extract returns the name from a leading "$name" or "${name}" in str.
+// extract returns the name from a leading "name" or "{name}" in str.
+// (The $ has already been removed by the caller.)
 // If it is a number, extract returns num set to that number; otherwise num = -1.
 func extract(str string) (name string, num int, rest string, ok bool) {
-	if len(str) < 2 || str[0] != '$' {
+	if str == "" {
 		return
 	}
 	brace := false
-	if str[1] == '{' {
+	if str[0] == '{' {
 		brace = true
-		str = str[2:]
-	} else {
 		str = str[1:]
 	}
 	i := 0
@@ -965,218 +1272,12 @@ func extract(str string) (name string, num int, rest string, ok bool) {
 	return
 }
 
-// FindSubmatchIndex returns a slice holding the index pairs identifying the
-// leftmost match of the regular expression in b and the matches, if any, of
-// its subexpressions, as defined by the 'Submatch' and 'Index' descriptions
-// in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindSubmatchIndex(b []byte) []int {
-	return re.pad(re.doExecute(nil, b, "", 0, re.prog.NumCap, nil))
-}
-
-// FindStringSubmatch returns a slice of strings holding the text of the
-// leftmost match of the regular expression in s and the matches, if any, of
-// its subexpressions, as defined by the 'Submatch' description in the
-// package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindStringSubmatch(s string) []string {
-	var dstCap [4]int
-	a := re.doExecute(nil, nil, s, 0, re.prog.NumCap, dstCap[:0])
-	if a == nil {
-		return nil
-	}
-	ret := make([]string, 1+re.numSubexp)
-	for i := range ret {
-		if 2*i < len(a) && a[2*i] >= 0 {
-			ret[i] = s[a[2*i]:a[2*i+1]]
-		}
-	}
-	return ret
-}
-
-// FindStringSubmatchIndex returns a slice holding the index pairs
-// identifying the leftmost match of the regular expression in s and the
-// matches, if any, of its subexpressions, as defined by the 'Submatch' and
-// 'Index' descriptions in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindStringSubmatchIndex(s string) []int {
-	return re.pad(re.doExecute(nil, nil, s, 0, re.prog.NumCap, nil))
-}
-
-// FindReaderSubmatchIndex returns a slice holding the index pairs
-// identifying the leftmost match of the regular expression of text read by
-// the RuneReader, and the matches, if any, of its subexpressions, as defined
-// by the 'Submatch' and 'Index' descriptions in the package comment. A
-// return value of nil indicates no match.
-func (re *Regexp) FindReaderSubmatchIndex(r io.RuneReader) []int {
-	return re.pad(re.doExecute(r, nil, "", 0, re.prog.NumCap, nil))
-}
-
-const startSize = 10 // The size at which to start a slice in the 'All' routines.
-
-// FindAll is the 'All' version of Find; it returns a slice of all successive
-// matches of the expression, as defined by the 'All' description in the
-// package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAll(b []byte, n int) [][]byte {
-	if n < 0 {
-		n = len(b) + 1
-	}
-	var result [][]byte
-	re.allMatches("", b, n, func(match []int) {
-		if result == nil {
-			result = make([][]byte, 0, startSize)
-		}
-		result = append(result, b[match[0]:match[1]])
-	})
-	return result
-}
-
-// FindAllIndex is the 'All' version of FindIndex; it returns a slice of all
-// successive matches of the expression, as defined by the 'All' description
-// in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllIndex(b []byte, n int) [][]int {
-	if n < 0 {
-		n = len(b) + 1
-	}
-	var result [][]int
-	re.allMatches("", b, n, func(match []int) {
-		if result == nil {
-			result = make([][]int, 0, startSize)
-		}
-		result = append(result, match[0:2])
-	})
-	return result
-}
-
-// FindAllString is the 'All' version of FindString; it returns a slice of all
-// successive matches of the expression, as defined by the 'All' description
-// in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllString(s string, n int) []string {
-	if n < 0 {
-		n = len(s) + 1
-	}
-	var result []string
-	re.allMatches(s, nil, n, func(match []int) {
-		if result == nil {
-			result = make([]string, 0, startSize)
-		}
-		result = append(result, s[match[0]:match[1]])
-	})
-	return result
-}
-
-// FindAllStringIndex is the 'All' version of FindStringIndex; it returns a
-// slice of all successive matches of the expression, as defined by the 'All'
-// description in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllStringIndex(s string, n int) [][]int {
-	if n < 0 {
-		n = len(s) + 1
-	}
-	var result [][]int
-	re.allMatches(s, nil, n, func(match []int) {
-		if result == nil {
-			result = make([][]int, 0, startSize)
-		}
-		result = append(result, match[0:2])
-	})
-	return result
-}
-
-// FindAllSubmatch is the 'All' version of FindSubmatch; it returns a slice
-// of all successive matches of the expression, as defined by the 'All'
-// description in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllSubmatch(b []byte, n int) [][][]byte {
-	if n < 0 {
-		n = len(b) + 1
-	}
-	var result [][][]byte
-	re.allMatches("", b, n, func(match []int) {
-		if result == nil {
-			result = make([][][]byte, 0, startSize)
-		}
-		slice := make([][]byte, len(match)/2)
-		for j := range slice {
-			if match[2*j] >= 0 {
-				slice[j] = b[match[2*j]:match[2*j+1]]
-			}
-		}
-		result = append(result, slice)
-	})
-	return result
-}
-
-// FindAllSubmatchIndex is the 'All' version of FindSubmatchIndex; it returns
-// a slice of all successive matches of the expression, as defined by the
-// 'All' description in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllSubmatchIndex(b []byte, n int) [][]int {
-	if n < 0 {
-		n = len(b) + 1
-	}
-	var result [][]int
-	re.allMatches("", b, n, func(match []int) {
-		if result == nil {
-			result = make([][]int, 0, startSize)
-		}
-		result = append(result, match)
-	})
-	return result
-}
-
-// FindAllStringSubmatch is the 'All' version of FindStringSubmatch; it
-// returns a slice of all successive matches of the expression, as defined by
-// the 'All' description in the package comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllStringSubmatch(s string, n int) [][]string {
-	if n < 0 {
-		n = len(s) + 1
-	}
-	var result [][]string
-	re.allMatches(s, nil, n, func(match []int) {
-		if result == nil {
-			result = make([][]string, 0, startSize)
-		}
-		slice := make([]string, len(match)/2)
-		for j := range slice {
-			if match[2*j] >= 0 {
-				slice[j] = s[match[2*j]:match[2*j+1]]
-			}
-		}
-		result = append(result, slice)
-	})
-	return result
-}
-
-// FindAllStringSubmatchIndex is the 'All' version of
-// FindStringSubmatchIndex; it returns a slice of all successive matches of
-// the expression, as defined by the 'All' description in the package
-// comment.
-// A return value of nil indicates no match.
-func (re *Regexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
-	if n < 0 {
-		n = len(s) + 1
-	}
-	var result [][]int
-	re.allMatches(s, nil, n, func(match []int) {
-		if result == nil {
-			result = make([][]int, 0, startSize)
-		}
-		result = append(result, match)
-	})
-	return result
-}
-
 // Split slices s into substrings separated by the expression and returns a slice of
 // the substrings between those expression matches.
 //
 // The slice returned by this method consists of all the substrings of s
-// not contained in the slice returned by FindAllString. When called on an expression
-// that contains no metacharacters, it is equivalent to strings.SplitN.
+// not contained in the slice returned by [Regexp.FindAllString]. When called on an expression
+// that contains no metacharacters, it is equivalent to [strings.SplitN].
 //
 // Example:
 //
@@ -1184,16 +1285,13 @@ func (re *Regexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
 //	// s: ["", "b", "b", "c", "cadaaae"]
 //
 // The count determines the number of substrings to return:
-//
-//	n > 0: at most n substrings; the last substring will be the unsplit remainder.
-//	n == 0: the result is nil (zero substrings)
-//	n < 0: all substrings
+//   - n > 0: at most n substrings; the last substring will be the unsplit remainder;
+//   - n == 0: the result is nil (zero substrings);
+//   - n < 0: all substrings.
 func (re *Regexp) Split(s string, n int) []string {
-
 	if n == 0 {
 		return nil
 	}
-
 	if len(re.expr) > 0 && len(s) == 0 {
 		return []string{""}
 	}
@@ -1221,3 +1319,32 @@ func (re *Regexp) Split(s string, n int) []string {
 
 	return strings
 }
+
+// AppendText implements [encoding.TextAppender]. The output
+// matches that of calling the [Regexp.String] method.
+//
+// Note that the output is lossy in some cases: This method does not indicate
+// POSIX regular expressions (i.e. those compiled by calling [CompilePOSIX]), or
+// those for which the [Regexp.Longest] method has been called.
+func (re *Regexp) AppendText(b []byte) ([]byte, error) {
+	return append(b, re.String()...), nil
+}
+
+// MarshalText implements [encoding.TextMarshaler]. The output
+// matches that of calling the [Regexp.AppendText] method.
+//
+// See [Regexp.AppendText] for more information.
+func (re *Regexp) MarshalText() ([]byte, error) {
+	return re.AppendText(nil)
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler] by calling
+// [Compile] on the encoded value.
+func (re *Regexp) UnmarshalText(text []byte) error {
+	newRE, err := Compile(string(text))
+	if err != nil {
+		return err
+	}
+	*re = *newRE
+	return nil
+}
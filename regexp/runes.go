@@ -16,6 +16,29 @@ func CompileAcme(expr string) (*Regexp, error) {
 	return compile(expr, syntax.Perl&^syntax.OneLine, false)
 }
 
+// Flags modify the matching of a regular expression compiled by
+// CompileAcmeFlags.
+type Flags int
+
+const (
+	FoldCase  Flags = 1 << iota // letters match either case
+	WholeWord                   // matches begin and end at word boundaries
+)
+
+// CompileAcmeFlags is like CompileAcme but matches as flags say. A word
+// boundary is as for \b, between an ASCII word character and another
+// character.
+func CompileAcmeFlags(expr string, flags Flags) (*Regexp, error) {
+	mode := syntax.Perl &^ syntax.OneLine
+	if flags&FoldCase != 0 {
+		mode |= syntax.FoldCase
+	}
+	if flags&WholeWord != 0 {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	return compile(expr, mode, false)
+}
+
 func MustCompileAcme(expr string) *Regexp {
 	if re, err := CompileAcme(expr); err != nil {
 		panic(err)
@@ -24,17 +47,32 @@ func MustCompileAcme(expr string) *Regexp {
 	}
 }
 
+// startSize is the size at which to start the slices of matches returned
+// by FindForward and FindBackward.
+const startSize = 10
+
 // FindForward is similar to FindAllSubmatchIndex but searches
 // r[start:end], taking care to match ^ and $ correctly.
 func (re *Regexp) FindForward(r []rune, start int, end int, n int) [][]int {
-	if n < 0 {
-		n = len(r) + 1
-	}
 	if end < 0 {
 		end = len(r)
 	}
+	return re.findForward(&inputRunes{str: r, start: start, end: end}, n)
+}
+
+// FindForwardText is like FindForward but searches t, which it reads a
+// chunk at a time.
+func (re *Regexp) FindForwardText(t Texter, start int, end int, n int) [][]int {
+	return re.findForward(newInputText(t, start, end), n)
+}
+
+func (re *Regexp) findForward(ri runeInput, n int) [][]int {
+	if n < 0 {
+		start, end := ri.bounds()
+		n = end - start + 1
+	}
 	var result [][]int
-	re.allMatchesRunes(r, start, end, n, func(match []int) {
+	re.allMatchesRunes(ri, n, func(match []int) {
 		if result == nil {
 			result = make([][]int, 0, startSize)
 		}
@@ -45,12 +83,8 @@ func (re *Regexp) FindForward(r []rune, start int, end int, n int) [][]int {
 
 // allMatchesRunes calls deliver at most n times
 // with the location of successive matches in the input text.
-func (re *Regexp) allMatchesRunes(r []rune, start int, end int, n int, deliver func([]int)) {
-	ri := &inputRunes{
-		str:   r,
-		start: start,
-		end:   end,
-	}
+func (re *Regexp) allMatchesRunes(ri runeInput, n int, deliver func([]int)) {
+	start, end := ri.bounds()
 	for pos, i, prevMatchEnd := start, 0, -1; i < n && pos <= end; {
 		matches := re.doExecuteInput(ri, pos, re.prog.NumCap, nil)
 		if len(matches) == 0 {
@@ -179,12 +213,36 @@ func (m *machine) matchRunes(i input, pos int) bool {
 	return m.matched
 }
 
+// runeInput is an input of runes with what the searches in this file and
+// runesb.go need besides.
+type runeInput interface {
+	input
+	bounds() (start, end int)        // the part of the text searched
+	sub(start, end int) runeInput    // the same text, searched in start:end
+	lastIndex(s []rune, end int) int // the last s in start:end, or -1
+}
+
 // inputRunes scans a rune sub-slice: str[start:end].
 type inputRunes struct {
 	str        []rune
 	start, end int
 }
 
+func (i *inputRunes) bounds() (int, int) {
+	return i.start, i.end
+}
+
+func (i *inputRunes) sub(start, end int) runeInput {
+	return &inputRunes{str: i.str, start: start, end: end}
+}
+
+func (i *inputRunes) lastIndex(s []rune, end int) int {
+	if q := runes.LastIndex(i.str[i.start:end], s); q >= 0 {
+		return i.start + q
+	}
+	return -1
+}
+
 func (i *inputRunes) step(pos int) (rune, int) {
 	if pos < i.end {
 		return i.str[pos], 1
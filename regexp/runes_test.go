@@ -66,6 +66,10 @@ func TestRegexpBackward(t *testing.T) {
 	tt := []runesTest{
 		{"aaaaa", 0, -1, "a", [][]int{{4, 5}, {3, 4}}, 2},
 		{"ab000ab000ab000", 0, -1, "ab", [][]int{{10, 12}, {5, 7}}, 2},
+		{"aaa", 0, -1, "aa", [][]int{{1, 3}}, 10},
+		{"xfoo foo", 2, -1, "foo", [][]int{{5, 8}}, 10},
+		{"foo foo xfoo", 0, 11, "foo", [][]int{{4, 7}, {0, 3}}, 10},
+		{"foo bar foo", 0, -1, "(foo)", [][]int{{8, 11, 8, 11}, {0, 3, 0, 3}}, 10},
 	}
 	for _, tc := range runesTests {
 		tc.expected = reverseMatches(tc.expected)
@@ -76,6 +80,41 @@ func TestRegexpBackward(t *testing.T) {
 	})
 }
 
+func TestRegexpFlags(t *testing.T) {
+	for _, tc := range []struct {
+		text       string
+		start, end int
+		re         string
+		flags      Flags
+		expected   [][]int
+	}{
+		{"Foo fOO foo", 0, -1, "foo", 0, [][]int{{8, 11}}},
+		{"Foo fOO foo", 0, -1, "foo", FoldCase, [][]int{{0, 3}, {4, 7}, {8, 11}}},
+		{"Straße STRASSE", 0, -1, "straße", FoldCase, [][]int{{0, 6}}},
+		{"foo food foo_ foo", 0, -1, "foo", WholeWord, [][]int{{0, 3}, {14, 17}}},
+		{"food foo", 1, -1, "ood|foo", WholeWord, [][]int{{5, 8}}},
+		{"Foo food FOO", 0, -1, "foo", FoldCase | WholeWord, [][]int{{0, 3}, {9, 12}}},
+	} {
+		re, err := CompileAcmeFlags(tc.re, tc.flags)
+		if err != nil {
+			t.Fatalf("CompileAcmeFlags(%q, %v) failed: %v", tc.re, tc.flags, err)
+		}
+		r := []rune(tc.text)
+		if got := re.FindForward(r, tc.start, tc.end, -1); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("regexp %q with flags %v forward in %q[%v:%v] gave %v; want %v",
+				tc.re, tc.flags, tc.text, tc.start, tc.end, got, tc.expected)
+		}
+		end := tc.end
+		if end < 0 {
+			end = len(r)
+		}
+		if got, want := re.FindBackward(r, tc.start, end, -1), reverseMatches(tc.expected); !reflect.DeepEqual(got, want) {
+			t.Errorf("regexp %q with flags %v backward in %q[%v:%v] gave %v; want %v",
+				tc.re, tc.flags, tc.text, tc.start, end, got, want)
+		}
+	}
+}
+
 func runRunesTests(t *testing.T, tt []runesTest, matcher func(*Regexp, *runesTest) [][]int) {
 	for i, tc := range tt {
 		t.Run(fmt.Sprintf("test-%02d", i), func(t *testing.T) {
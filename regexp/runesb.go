@@ -13,7 +13,16 @@ const suffixwindowsize = 500
 
 // newFindBackward is simple O(n) implementation of backwards find.
 func (re *Regexp) newFindBackward(r []rune, start int, end int, n int) [][]int {
-	// log.Println("newFindBackward", "len(r)", len(r), "start", start, "end", end, "n", n)
+	if end < 0 {
+		end = len(r)
+	}
+	return re.nfaFindBackward(&inputRunes{str: r, start: start, end: end}, n)
+}
+
+// nfaFindBackward searches forward through a suffix of the input, or the
+// whole of it if the suffix has too few matches, keeping the last n.
+func (re *Regexp) nfaFindBackward(ri runeInput, n int) [][]int {
+	start, end := ri.bounds()
 
 	ffstart := start
 
@@ -22,14 +31,14 @@ func (re *Regexp) newFindBackward(r []rune, start int, end int, n int) [][]int {
 		ffstart = end - n*suffixwindowsize
 	}
 
-	forwardmatches := re.FindForward(r, ffstart, end, -1)
+	forwardmatches := re.lastMatches(ri.sub(ffstart, end), n)
 	nfw := len(forwardmatches)
 
 	if start != ffstart && (nfw < n || n < 0) {
 		// Maybe the desired number of matches exist in the whole. (Prefix is
 		// insufficient because the regexp might match a substring overlapping
 		// the arbitrarily chosen split point.)
-		forwardmatches = re.FindForward(r, start, end, -1)
+		forwardmatches = re.lastMatches(ri, n)
 		nfw = len(forwardmatches)
 	}
 
@@ -37,21 +46,84 @@ func (re *Regexp) newFindBackward(r []rune, start int, end int, n int) [][]int {
 		return nil
 	}
 
-	if n < 0 || n > nfw {
-		n = nfw
-	}
-
-	matches := make([][]int, 0, n)
-
-	for i := nfw - 1; len(matches) < n; i-- {
+	matches := make([][]int, 0, nfw)
+	for i := nfw - 1; i >= 0; i-- {
 		matches = append(matches, forwardmatches[i])
 	}
 
 	return matches
 }
 
+// lastMatches returns the last n matches in the input, or all of them if
+// n < 0, first first.
+func (re *Regexp) lastMatches(ri runeInput, n int) [][]int {
+	start, end := ri.bounds()
+	var matches [][]int
+	re.allMatchesRunes(ri, end-start+1, func(match []int) {
+		if n >= 0 && len(matches) == n {
+			if n == 0 {
+				return
+			}
+			copy(matches, matches[1:])
+			matches = matches[:n-1]
+		}
+		matches = append(matches, match)
+	})
+	return matches
+}
+
+// FindBackward searches r[start:end] from the end and returns at most n
+// matches, the last first.
 func (re *Regexp) FindBackward(r []rune, start int, end int, n int) [][]int {
-	return re.newFindBackward(r, start, end, n)
+	if end < 0 {
+		end = len(r)
+	}
+	return re.findBackward(&inputRunes{str: r, start: start, end: end}, n)
+}
+
+// FindBackwardText is like FindBackward but searches t, which it reads a
+// chunk at a time.
+func (re *Regexp) FindBackwardText(t Texter, start int, end int, n int) [][]int {
+	return re.findBackward(newInputText(t, start, end), n)
+}
+
+func (re *Regexp) findBackward(ri runeInput, n int) [][]int {
+	if re.prefixComplete && re.prefix != "" && re.cond&syntax.EmptyBeginText == 0 {
+		return re.literalFindBackward(ri, n)
+	}
+	return re.nfaFindBackward(ri, n)
+}
+
+// literalFindBackward is FindBackward for a regexp that matches only a
+// literal string. It looks for the string from the end, so the time it
+// takes depends on how far back the matches are rather than on the size
+// of the input. Where the string can overlap itself, as "aa" in "aaa"
+// can, the matches are those that end last.
+func (re *Regexp) literalFindBackward(ri runeInput, n int) [][]int {
+	start, end := ri.bounds()
+	if n < 0 {
+		n = end - start + 1
+	}
+	lit := []rune(re.prefix)
+	var result [][]int
+	for len(result) < n {
+		p := ri.lastIndex(lit, end)
+		if p < 0 {
+			break
+		}
+		// Run the matcher from p to fill in any parenthesized
+		// subexpressions.
+		match := re.doExecuteInput1(ri, p, re.prog.NumCap, nil)
+		if match == nil {
+			break
+		}
+		if result == nil {
+			result = make([][]int, 0, startSize)
+		}
+		result = append(result, re.pad(match))
+		end = p
+	}
+	return result
 }
 
 // oldFindBackward is similar to FindAllSubmatchIndex but searches
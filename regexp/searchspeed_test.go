@@ -96,6 +96,35 @@ func BenchmarkOldFindBackwardOne(b *testing.B) {
 	}
 }
 
+// BenchmarkFindBackwardFar looks back from the end of a large text for a
+// literal found only at the start, as ?package? does.
+func BenchmarkFindBackwardFar(b *testing.B) {
+	r := append([]rune("package "), readLargeFile(b, 10000)[len("package "):]...)
+	for i := range r[1:] {
+		if r[i+1] == 'p' {
+			r[i+1] = 'P'
+		}
+	}
+	re, err := CompileAcme("package")
+	if err != nil {
+		b.Fatalf("can't compile regexp %q: %v", "package", err)
+	}
+	b.Run("literal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if matches := re.FindBackward(r, 0, len(r), 1); len(matches) != 1 {
+				b.Errorf("wrong # of matches got %d want 1", len(matches))
+			}
+		}
+	})
+	b.Run("nfa", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if matches := re.newFindBackward(r, 0, len(r), 1); len(matches) != 1 {
+				b.Errorf("wrong # of matches got %d want 1", len(matches))
+			}
+		}
+	})
+}
+
 func TestFindForward(t *testing.T) {
 	r := readLargeFile(t, 10)
 	re := makeRe(t)
@@ -0,0 +1,122 @@
+package regexp
+
+// Texter is text that is not held in one []rune, such as the piece table
+// of a file. FindForwardText and FindBackwardText search it a chunk at a
+// time, so they need memory in proportion to the chunk size rather than
+// to the size of the text. sam.Texter satisfies Texter.
+type Texter interface {
+	Nc() int                                  // the number of runes
+	ReadB(q int, r []rune) (n int, err error) // read runes from q into r
+}
+
+// textChunk is the number of runes that inputText reads at a time.
+const textChunk = 4096
+
+// inputText scans t[start:end]. It keeps the chunk of t around the
+// position it last read.
+type inputText struct {
+	t          Texter
+	nc         int
+	start, end int
+	buf        []rune // t[base:base+len(buf)]
+	base       int
+	prefix     []rune // the prefix of the regexp, for index
+}
+
+func newInputText(t Texter, start, end int) *inputText {
+	nc := t.Nc()
+	if end < 0 || end > nc {
+		end = nc
+	}
+	return &inputText{
+		t:     t,
+		nc:    nc,
+		start: start,
+		end:   end,
+	}
+}
+
+// at returns the rune at pos. A pos before the chunk read last is
+// probably from a backward search, so most of the new chunk is before
+// pos. Otherwise the chunk starts just before pos, keeping the rune that
+// context needs.
+func (i *inputText) at(pos int) rune {
+	if pos < i.base || pos >= i.base+len(i.buf) {
+		base := pos - 1
+		if pos < i.base {
+			base = pos - textChunk + textChunk/8
+		}
+		base = max(0, min(base, i.nc-textChunk))
+		if i.buf == nil {
+			i.buf = make([]rune, textChunk)
+		}
+		n, _ := i.t.ReadB(base, i.buf[:min(textChunk, i.nc-base)])
+		i.buf, i.base = i.buf[:n], base
+	}
+	return i.buf[pos-i.base]
+}
+
+func (i *inputText) bounds() (int, int) {
+	return i.start, i.end
+}
+
+func (i *inputText) sub(start, end int) runeInput {
+	return newInputText(i.t, start, end)
+}
+
+func (i *inputText) step(pos int) (rune, int) {
+	if pos < i.end {
+		return i.at(pos), 1
+	}
+	return endOfText, 0
+}
+
+func (i *inputText) canCheckPrefix() bool {
+	return true
+}
+
+func (i *inputText) hasPrefix(re *Regexp) bool {
+	return i.index(re, i.start) == 0
+}
+
+func (i *inputText) index(re *Regexp, pos int) int {
+	if i.prefix == nil {
+		i.prefix = []rune(re.prefix)
+	}
+	for q := pos; q+len(i.prefix) <= i.end; q++ {
+		if i.matchAt(q, i.prefix) {
+			return q - pos
+		}
+	}
+	return -1
+}
+
+func (i *inputText) lastIndex(s []rune, end int) int {
+	for q := end - len(s); q >= i.start; q-- {
+		if i.matchAt(q, s) {
+			return q
+		}
+	}
+	return -1
+}
+
+// matchAt reports whether the text at q begins with s.
+func (i *inputText) matchAt(q int, s []rune) bool {
+	for k, r := range s {
+		if i.at(q+k) != r {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *inputText) context(pos int) lazyFlag {
+	r1, r2 := endOfText, endOfText
+	if 0 < pos && pos <= i.nc {
+		r1 = i.at(pos - 1)
+	}
+	if 0 <= pos && pos < i.nc {
+		r2 = i.at(pos)
+	}
+	return newLazyFlag(r1, r2)
+}
@@ -0,0 +1,63 @@
+package regexp
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runeTexter is a Texter that records the largest read.
+type runeTexter struct {
+	r       []rune
+	maxread int
+}
+
+func (t *runeTexter) Nc() int { return len(t.r) }
+
+func (t *runeTexter) ReadB(q int, r []rune) (int, error) {
+	t.maxread = max(t.maxread, len(r))
+	n := copy(r, t.r[q:])
+	if n < len(r) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func TestFindText(t *testing.T) {
+	// Matches that cross the boundaries of the chunks read.
+	long := strings.Repeat("ab\n", 3*textChunk)
+	long = long[:textChunk-2] + "needle" + long[textChunk+4:2*textChunk-1] + "\nneedle" + long[2*textChunk+6:]
+
+	tt := append([]runesTest{
+		{long, 0, -1, "needle", nil, -1},
+		{long, 0, -1, "^needle", nil, -1},
+		{long, 0, -1, "ne+dle", nil, -1},
+		{long, 0, -1, "(b|\n)needle", nil, -1},
+		{long, 0, -1, "b\na", nil, 3},
+		{long, textChunk, -1, "needle", nil, -1},
+	}, runesTests...)
+	for _, tc := range tt {
+		re := MustCompileAcme(tc.re)
+		r := []rune(tc.text)
+		end := tc.end
+		if end < 0 {
+			end = len(r)
+		}
+		text := &runeTexter{r: r}
+		want := re.FindForward(r, tc.start, end, tc.nmax)
+		if tc.text == long && want == nil {
+			t.Errorf("no match for %q in the long text", tc.re)
+		}
+		if got := re.FindForwardText(text, tc.start, end, tc.nmax); !reflect.DeepEqual(got, want) {
+			t.Errorf("FindForwardText %q in %.20q[%v:%v] gave %v; want %v", tc.re, tc.text, tc.start, end, got, want)
+		}
+		want = re.FindBackward(r, tc.start, end, tc.nmax)
+		if got := re.FindBackwardText(text, tc.start, end, tc.nmax); !reflect.DeepEqual(got, want) {
+			t.Errorf("FindBackwardText %q in %.20q[%v:%v] gave %v; want %v", tc.re, tc.text, tc.start, end, got, want)
+		}
+		if text.maxread > textChunk {
+			t.Errorf("searching %.20q read %d runes at once; want at most %d", tc.text, text.maxread, textChunk)
+		}
+	}
+}
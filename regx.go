@@ -5,8 +5,6 @@ import (
 	"github.com/rjkroege/edwood/sam"
 )
 
-// TODO(rjk): Regexps should stream. We need a forward/back Rune streaming interface.
-
 // AcmeRegexp is the representation of a compiled regular expression for acme.
 type AcmeRegexp struct {
 	*regexp.Regexp
@@ -15,7 +13,13 @@ type AcmeRegexp struct {
 // rxcompile parses a regular expression and returns a regular expression object
 // that can be used to match against text.
 func rxcompile(r string) (*AcmeRegexp, error) {
-	re, err := regexp.CompileAcme(r)
+	return rxcompileflags(r, 0)
+}
+
+// rxcompileflags is like rxcompile but matches as flags say, such as
+// without regard to case.
+func rxcompileflags(r string, flags regexp.Flags) (*AcmeRegexp, error) {
+	re, err := regexp.CompileAcmeFlags(r, flags)
 	if err != nil {
 		return nil, err
 	}
@@ -25,23 +29,19 @@ func rxcompile(r string) (*AcmeRegexp, error) {
 }
 
 // rxexecute searches forward in r[start:end] (from beginning of the slice to the end)
-// and returns at most n matches. If r is nil, it is derived from t.
+// and returns at most n matches. If r is nil, it searches t, reading it a
+// chunk at a time.
 func (re *AcmeRegexp) rxexecute(t sam.Texter, r []rune, start int, end int, n int) []RangeSet {
 	if r == nil {
-		// TODO(rjk): This is horrible. Stream here instead.
-		r = make([]rune, t.Nc())
-		t.ReadB(0, r[:t.Nc()])
+		return matchesToRangeSets(re.FindForwardText(t, start, end, n))
 	}
 	return matchesToRangeSets(re.FindForward(r, start, end, n))
 }
 
-// rxbexecute derives the full rune slice r from t and searches backwards in r[:end]
-// (from end of the slice to the beginning) and returns at most n matches.
+// rxbexecute searches t backwards from end (from end of the text to the
+// beginning) and returns at most n matches.
 func (re *AcmeRegexp) rxbexecute(t sam.Texter, end int, n int) RangeSet {
-	// TODO(rjk): This is horrible. Stream here instead.
-	r := make([]rune, t.Nc())
-	t.ReadB(0, r[:t.Nc()])
-	matches := re.FindBackward(r, 0, end, n)
+	matches := re.FindBackwardText(t, 0, end, n)
 	var rs RangeSet
 	for _, m := range matches {
 		rs = append(rs, Range{
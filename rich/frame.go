@@ -1375,6 +1375,8 @@ func buildLineRegionIndex(numLines int, adjustedRegions []AdjustedBlockRegion) [
 //     2b. selection highlight     — between backgrounds and text, so
 //     backgrounds don't overdraw it.
 //  4. text                    — on top of backgrounds.
+//     4b. decorations             — underline, strike and squiggle
+//     lines over the text.
 //  5. images and fixed boxes  — at their layout positions.
 //     5b. gutter repaint          — clips horizontal-scroll overflow
 //     that crossed into the gutter.
@@ -1396,6 +1398,7 @@ func (f *frameImpl) drawTextTo(target edwooddraw.Image, offset image.Point) {
 	f.paintPhaseBoxBackgrounds(c)
 	f.paintPhaseSelectionHighlight(c)
 	f.paintPhaseText(c)
+	f.paintPhaseDecorations(c)
 	f.paintPhaseImagesAndFixedBoxes(c)
 	f.paintPhaseGutterRepaint(c)
 	f.paintPhaseHScrollbars(c)
@@ -1499,6 +1502,69 @@ func (f *frameImpl) paintPhaseText(c *paintCtx) {
 	}
 }
 
+// paintPhaseDecorations draws underline, strikethrough and squiggle
+// lines for text boxes that request them (Phase 4b). Underline and
+// strike use the box's text color; squiggle uses its own. Shifted by
+// -hOffset inside scrollable block regions so the lines track the text.
+func (f *frameImpl) paintPhaseDecorations(c *paintCtx) {
+	for lineIdx, line := range c.lines {
+		if line.Y >= c.frameHeight {
+			break
+		}
+		hOff := f.hOffsetForLine(c, lineIdx)
+		for _, pb := range line.Boxes {
+			st := pb.Box.Style
+			if !st.Underline && !st.Strike && st.Squiggle == nil {
+				continue
+			}
+			if pb.Box.IsNewline() || pb.Box.IsFixedBox() || pb.Box.Wid <= 0 {
+				continue
+			}
+			x0 := c.offset.X + pb.X - hOff
+			x1 := x0 + pb.Box.Wid
+			top := c.offset.Y + line.Y
+			h := f.fontHeightForStyle(st)
+
+			lineColorImg := f.textColor
+			if st.Fg != nil {
+				if colorImg := f.allocColorImage(st.Fg); colorImg != nil {
+					lineColorImg = colorImg
+				}
+			}
+			if st.Underline && lineColorImg != nil {
+				y := top + h - 1
+				c.target.Draw(image.Rect(x0, y, x1, y+1), lineColorImg, nil, image.ZP)
+			}
+			if st.Strike && lineColorImg != nil {
+				y := top + h/2
+				c.target.Draw(image.Rect(x0, y, x1, y+1), lineColorImg, nil, image.ZP)
+			}
+			if st.Squiggle != nil {
+				if colorImg := f.allocColorImage(st.Squiggle); colorImg != nil {
+					drawSquiggle(c.target, colorImg, x0, x1, top+h-2)
+				}
+			}
+		}
+	}
+}
+
+// squigglePeriod is the horizontal length in pixels of one rise or
+// fall of a squiggle.
+const squigglePeriod = 2
+
+// drawSquiggle draws a two-pixel-high zigzag from x0 to x1 with its
+// top at y.
+func drawSquiggle(target edwooddraw.Image, col edwooddraw.Image, x0, x1, y int) {
+	for x := x0; x < x1; x += squigglePeriod {
+		dy := 0
+		if ((x-x0)/squigglePeriod)%2 == 1 {
+			dy = 1
+		}
+		end := min(x+squigglePeriod, x1)
+		target.Draw(image.Rect(x, y+dy, end, y+dy+1), col, nil, image.ZP)
+	}
+}
+
 // paintPhaseImagesAndFixedBoxes renders inline images, image
 // placeholders (loading / error), and fixed-rectangle boxes (Phase 5).
 // Images within a scrollable block region are shifted by -hOffset;
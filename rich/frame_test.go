@@ -5131,3 +5131,76 @@ func TestPaintImageBelowPositioning(t *testing.T) {
 		}
 	})
 }
+
+// TestDrawTextDecorations verifies that underline, strike and squiggle
+// styles paint lines after the text, within the box's horizontal extent.
+func TestDrawTextDecorations(t *testing.T) {
+	rect := image.Rect(0, 0, 400, 300)
+	display := edwoodtest.NewDisplay(rect)
+	font := edwoodtest.NewFont(10, 14)
+
+	bgImage := edwoodtest.NewImage(display, "frame-background", image.Rect(0, 0, 1, 1))
+	textImage := edwoodtest.NewImage(display, "text-color", image.Rect(0, 0, 1, 1))
+
+	f := NewFrame()
+	f.Init(WithDisplay(display), WithBackground(bgImage), WithFont(font), WithTextColor(textImage))
+	f.SetRect(rect)
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	tests := []struct {
+		name  string
+		style Style
+		want  []image.Rectangle
+	}{
+		{"underline", Style{Underline: true, Scale: 1.0}, []image.Rectangle{image.Rect(40, 13, 80, 14)}},
+		{"strike", Style{Strike: true, Scale: 1.0}, []image.Rectangle{image.Rect(40, 7, 80, 8)}},
+		{"squiggle", Style{Squiggle: red, Scale: 1.0}, []image.Rectangle{
+			image.Rect(40, 12, 42, 13),
+			image.Rect(42, 13, 44, 14),
+			image.Rect(78, 13, 80, 14),
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f.SetContent(Content{
+				{Text: "one ", Style: DefaultStyle()},
+				{Text: "word", Style: tc.style},
+			})
+			display.(edwoodtest.GettableDrawOps).Clear()
+			f.Redraw()
+			ops := display.(edwoodtest.GettableDrawOps).DrawOps()
+
+			textIdx := -1
+			for i, op := range ops {
+				if strings.Contains(op, `string "word"`) {
+					textIdx = i
+				}
+			}
+			if textIdx < 0 {
+				t.Fatalf("did not find text op; ops: %v", ops)
+			}
+			for _, r := range tc.want {
+				found := false
+				for _, op := range ops[textIdx+1:] {
+					if strings.Contains(op, r.String()) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("no decoration draw at %v after the text; ops: %v", r, ops)
+				}
+			}
+		})
+	}
+
+	// Plain text has no decorations.
+	f.SetContent(Content{{Text: "word", Style: DefaultStyle()}})
+	display.(edwoodtest.GettableDrawOps).Clear()
+	f.Redraw()
+	for _, op := range display.(edwoodtest.GettableDrawOps).DrawOps() {
+		if strings.Contains(op, "-(40,14)") {
+			t.Errorf("unexpected decoration op for undecorated text: %q", op)
+		}
+	}
+}
@@ -16,6 +16,11 @@ type Style struct {
 	Block  bool // Block-level element (full-width background for fenced code blocks)
 	HRule  bool // Horizontal rule marker (draw line instead of text)
 
+	// Text decorations
+	Underline bool        // Line under the text in the foreground color
+	Strike    bool        // Line through the text in the foreground color
+	Squiggle  color.Color // Wavy underline color (nil means none)
+
 	// Layout hints
 	ParaBreak  bool // Paragraph break - adds extra vertical spacing
 	SlideBreak bool // Second HRule in a pair (slide boundary marker)
@@ -515,6 +515,7 @@ func (row *Row) loadhelper(win *dumpfile.Window) error {
 	} else if win.Type != dumpfile.Zerox && len(subl[0]) > 0 && subl[0][0] != '+' && subl[0][0] != '-' {
 		// Implementation of the Get command: open the file.
 		get(&w.body, nil, nil, false, false, "")
+		restoreUndoJournal(&w.body)
 	}
 
 	if win.Font != "" {
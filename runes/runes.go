@@ -31,6 +31,23 @@ func Index(s, sep []rune) int {
 	return -1
 }
 
+// LastIndex returns the index of the last instance of sep in s, or -1 if sep is not present in s.
+func LastIndex(s, sep []rune) int {
+	n := len(sep)
+	switch {
+	case n > len(s):
+		return -1
+	case n == 0:
+		return len(s)
+	}
+	for i := len(s) - n; i >= 0; i-- {
+		if s[i] == sep[0] && HasPrefix(s[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
 // IndexRune returns the index of the first occurrence in s of the given rune r.
 // It returns -1 if rune is not present in s.
 func IndexRune(s []rune, r rune) int {
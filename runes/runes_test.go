@@ -34,6 +34,31 @@ func TestIndex(t *testing.T) {
 	}
 }
 
+func TestLastIndex(t *testing.T) {
+	tt := []struct {
+		s, sep string
+		n      int
+	}{
+		{"foobar", "", 6},
+		{"", "abc", -1},
+		{"abc", "abcd", -1},
+		{"x", "x", 0},
+		{"fooabcbar", "foo", 0},
+		{"fooabcbar", "bar", 6},
+		{"fooabcbar", "xyz", -1},
+		{"abcfooabc", "abc", 6},
+		{"aaa", "aa", 1},
+		{"私はガラスを食べる", "ガラス", 2},
+		{"私は私", "私", 2},
+	}
+	for _, tc := range tt {
+		n := LastIndex([]rune(tc.s), []rune(tc.sep))
+		if n != tc.n {
+			t.Errorf("LastIndex(%q, %q) is %v; expected %v", tc.s, tc.sep, n, tc.n)
+		}
+	}
+}
+
 func TestHasPrefix(t *testing.T) {
 	tt := []struct {
 		s, prefix string
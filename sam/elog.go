@@ -210,6 +210,34 @@ func (e *Elog) Delete(q0, q1 int) error {
 	return err
 }
 
+// Map returns where the text at q0, q1 is once the log is applied. An
+// edge inside changed text moves to the start of the change, or to the
+// end for q1. Like the selection in Apply, text inserted at q0 is
+// included, as is text inserted at q1 if the range is empty.
+func (e *Elog) Map(q0, q1 int) (p0, p1 int) {
+	p0, p1 = q0, q1
+	for _, eo := range e.Log[1:] {
+		nr := 0
+		if eo.T == Insert || eo.T == Replace {
+			nr = len(eo.r)
+		}
+		end := eo.q0 + eo.nd
+		switch {
+		case end < q0 || end == q0 && eo.nd > 0:
+			p0 += nr - eo.nd
+		case eo.q0 < q0:
+			p0 -= q0 - eo.q0
+		}
+		switch {
+		case end < q1 || end == q1 && (eo.nd > 0 || q0 == q1):
+			p1 += nr - eo.nd
+		case eo.q0 < q1:
+			p1 += nr - (q1 - eo.q0)
+		}
+	}
+	return p0, p1
+}
+
 const tracelog = false
 
 func (e *Elog) Empty() bool {
@@ -142,3 +142,40 @@ func TestApply(t *testing.T) {
 		}
 	}
 }
+
+func TestMap(t *testing.T) {
+	// "0123456789" becomes "0ab2345Z89": insert at 1, replace 1 with ab,
+	// delete 6-8 and insert Z at 8.
+	e := MakeElog()
+	e.Insert(1, []rune("a"))
+	e.Replace(1, 2, []rune("b"))
+	e.Delete(6, 8)
+	e.Insert(8, []rune("Z"))
+
+	for _, tc := range []struct {
+		q0, q1 int
+		p0, p1 int
+	}{
+		{0, 0, 0, 0},
+		{0, 1, 0, 1},
+		{1, 1, 1, 2},   // empty at an insertion: covers it
+		{1, 2, 1, 3},   // replaced text: covers the replacement
+		{2, 2, 3, 3},   // after the replacement
+		{2, 5, 3, 6},   // shifted
+		{5, 7, 6, 7},   // end inside a deletion
+		{7, 9, 7, 9},   // start inside a deletion
+		{6, 8, 7, 7},   // deleted
+		{8, 8, 7, 8},   // empty at the insertion after the deletion
+		{9, 10, 9, 10}, // after everything
+	} {
+		if p0, p1 := e.Map(tc.q0, tc.q1); p0 != tc.p0 || p1 != tc.p1 {
+			t.Errorf("Map(%d, %d) = %d, %d; want %d, %d", tc.q0, tc.q1, p0, p1, tc.p0, tc.p1)
+		}
+	}
+
+	tb := NewTextBuffer(0, 0, []rune("0123456789"))
+	e.Apply(tb)
+	if got, want := string(tb.buf), "0ab2345Z89"; got != want {
+		t.Errorf("Apply gave %q; want %q", got, want)
+	}
+}
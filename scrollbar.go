@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"image"
+	"slices"
 	"time"
 
 	"github.com/rjkroege/edwood/draw"
@@ -105,8 +106,22 @@ type Scrollbar struct {
 	rect           image.Rectangle // current scrollbar rectangle on the screen
 	lastDrawnThumb image.Rectangle // last drawn thumb rectangle (dirty cache)
 	tmp            draw.Image      // off-screen scratch image, lazily allocated
+
+	// markers are ticks drawn over the track at fixed fractions of the
+	// document (e.g. one per diagnostic). Set with SetMarkers.
+	markers []ScrollMarker
+}
+
+// ScrollMarker is a tick painted on the scrollbar at Frac (in [0, 1])
+// of the way down the document, in color Color.
+type ScrollMarker struct {
+	Frac  float64
+	Color draw.Image
 }
 
+// markerHeight is the height in pixels of a ScrollMarker tick.
+const markerHeight = 2
+
 // NewScrollbar constructs a Scrollbar bound to the given display and
 // model. Callers must call SetRect before Draw.
 func NewScrollbar(display draw.Display, model ScrollModel, track, thumb draw.Image) *Scrollbar {
@@ -151,6 +166,17 @@ func (s *Scrollbar) Free() {
 	s.lastDrawnThumb = image.Rectangle{}
 }
 
+// SetMarkers replaces the scrollbar's markers. The dirty cache is
+// invalidated only if the markers changed, so callers may call this
+// on every edit.
+func (s *Scrollbar) SetMarkers(markers []ScrollMarker) {
+	if slices.Equal(s.markers, markers) {
+		return
+	}
+	s.markers = markers
+	s.lastDrawnThumb = image.Rectangle{}
+}
+
 // Draw renders the scrollbar (track, thumb, edge) into its current
 // rect on the display's screen image. Cheap: skips the blit if the
 // thumb rectangle is identical to the last drawn one.
@@ -197,6 +223,9 @@ func (s *Scrollbar) renderThumb(thumbRect image.Rectangle) {
 	if edge.Min.X < edge.Max.X {
 		s.tmp.Draw(edge, s.track, nil, image.Point{})
 	}
+	for _, m := range s.markers {
+		s.tmp.Draw(markerRect(local, m.Frac), m.Color, nil, image.Point{})
+	}
 	s.display.ScreenImage().Draw(s.rect, s.tmp, nil, image.Point{X: 0, Y: local.Min.Y})
 }
 
@@ -245,6 +274,16 @@ func computeThumbRect(track image.Rectangle, totalPx, viewPx, originPx int) imag
 	return clampThumbHeight(q, track)
 }
 
+// markerRect returns the rectangle of a marker at fraction frac of
+// the way down track. Markers occupy the right half of the track, so
+// the thumb stays visible beside them, and never extend past its ends.
+func markerRect(track image.Rectangle, frac float64) image.Rectangle {
+	frac = max(0, min(frac, 1))
+	y := track.Min.Y + int(frac*float64(track.Dy()))
+	y = min(y, track.Max.Y-markerHeight)
+	return image.Rect((track.Min.X+track.Max.X)/2, y, track.Max.X-1, y+markerHeight).Intersect(track)
+}
+
 // clampThumbHeight enforces MinThumbHeightPx on the thumb rectangle.
 // If shorter, it extends down; if there isn't room within the track,
 // it pins to the bottom edge. Matches the legacy logic
@@ -473,3 +473,49 @@ func withGlobalMouseY(t *testing.T, y int, fn func()) {
 // either a real X server or invasive abstraction work. Verification
 // of the loop is part of the manual test pass in
 // PLAN_unified-scrollbar.md §2.3.
+
+func TestScrollbar_MarkersAddOneOpEach(t *testing.T) {
+	s, _, display := scrollbarTestHarness(t)
+	s.SetMarkers([]ScrollMarker{
+		{Frac: 0.1, Color: display.Black()},
+		{Frac: 0.9, Color: display.Black()},
+	})
+	display.(edwoodtest.GettableDrawOps).Clear()
+	s.Draw()
+	if got, want := len(display.(edwoodtest.GettableDrawOps).DrawOps()), expectedFirstDrawOps+2; got != want {
+		t.Errorf("Draw with two markers recorded %d ops; want %d", got, want)
+	}
+}
+
+func TestScrollbar_SetMarkersInvalidatesOnlyOnChange(t *testing.T) {
+	s, _, display := scrollbarTestHarness(t)
+	black := display.Black()
+	s.SetMarkers([]ScrollMarker{{Frac: 0.5, Color: black}})
+	s.Draw()
+
+	s.SetMarkers([]ScrollMarker{{Frac: 0.5, Color: black}})
+	if s.lastDrawnThumb.Empty() {
+		t.Error("SetMarkers with equal markers must not invalidate the cache")
+	}
+	s.SetMarkers(nil)
+	if !s.lastDrawnThumb.Empty() {
+		t.Error("SetMarkers with new markers must invalidate the cache")
+	}
+}
+
+func TestMarkerRect(t *testing.T) {
+	track := image.Rect(0, 0, 12, 100)
+	for _, tc := range []struct {
+		frac float64
+		want image.Rectangle
+	}{
+		{0, image.Rect(6, 0, 11, 2)},
+		{0.5, image.Rect(6, 50, 11, 52)},
+		{1, image.Rect(6, 98, 11, 100)},
+		{2, image.Rect(6, 98, 11, 100)},
+	} {
+		if got := markerRect(track, tc.frac); !got.Eq(tc.want) {
+			t.Errorf("markerRect(%v, %v) = %v; want %v", track, tc.frac, got, tc.want)
+		}
+	}
+}
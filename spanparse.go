@@ -266,9 +266,10 @@ func parseSpanLine(fields []string) (offset, length int, run StyleRun, err error
 		}
 	}
 
-	var bold, italic, hidden, hrule bool
+	var bold, italic, hidden, hrule, underline, strike bool
 	var scale float64
 	var family string
+	var squiggle color.Color
 	for _, flag := range fields[flagStart:] {
 		switch {
 		case flag == "bold":
@@ -279,6 +280,16 @@ func parseSpanLine(fields []string) (offset, length int, run StyleRun, err error
 			hidden = true
 		case flag == "hrule":
 			hrule = true
+		case flag == "underline":
+			underline = true
+		case flag == "strike":
+			strike = true
+		case strings.HasPrefix(flag, "squiggle="):
+			c, perr := parseSquiggleFlag(flag)
+			if perr != nil {
+				return 0, 0, StyleRun{}, perr
+			}
+			squiggle = c
 		case strings.HasPrefix(flag, "scale="):
 			s, perr := parseScaleFlag(flag)
 			if perr != nil {
@@ -299,19 +310,34 @@ func parseSpanLine(fields []string) (offset, length int, run StyleRun, err error
 	run = StyleRun{
 		Len: length,
 		Style: StyleAttrs{
-			Fg:     fg,
-			Bg:     bg,
-			Bold:   bold,
-			Italic: italic,
-			Hidden: hidden,
-			Scale:  scale,
-			Family: family,
-			HRule:  hrule,
+			Fg:        fg,
+			Bg:        bg,
+			Bold:      bold,
+			Italic:    italic,
+			Hidden:    hidden,
+			Scale:     scale,
+			Family:    family,
+			HRule:     hrule,
+			Underline: underline,
+			Strike:    strike,
+			Squiggle:  squiggle,
 		},
 	}
 	return offset, length, run, nil
 }
 
+// parseSquiggleFlag parses a "squiggle=#rrggbb" flag token and
+// returns the squiggle color. Unlike fg/bg, "-" is rejected: a
+// squiggle with the default color has no meaning; producers
+// omit the flag instead.
+func parseSquiggleFlag(flag string) (color.Color, error) {
+	val := strings.TrimPrefix(flag, "squiggle=")
+	if val == "" || val == "-" {
+		return nil, fmt.Errorf("squiggle flag needs a color: %q", flag)
+	}
+	return parseColor(val)
+}
+
 // maxScale caps the scale=N.N flag at a reasonable upper bound.
 // Values above this render at degenerate sizes; the parser
 // rejects them to surface producer bugs rather than silently
@@ -1664,3 +1664,77 @@ func TestParseBoxPayloadWithParams(t *testing.T) {
 		t.Errorf("BoxPayload = %q, want %q", runs[0].Style.BoxPayload, want)
 	}
 }
+
+// --- underline / strike / squiggle flags --------------------------------
+
+func TestParseSpanDecorations(t *testing.T) {
+	data := "s 0 5 #0000cc underline strike squiggle=#ff0000"
+	runs, _, _, _, err := parseSpanMessage(data, 100)
+	if err != nil {
+		t.Fatalf("parseSpanMessage: %v", err)
+	}
+	st := runs[0].Style
+	if !st.Underline {
+		t.Error("Underline should be true")
+	}
+	if !st.Strike {
+		t.Error("Strike should be true")
+	}
+	if !colorEqual(st.Squiggle, color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("Squiggle = %v, want #ff0000", st.Squiggle)
+	}
+	if !colorEqual(st.Fg, color.RGBA{B: 0xcc, A: 0xff}) {
+		t.Errorf("Fg = %v, want #0000cc (decorations must not change fg)", st.Fg)
+	}
+}
+
+// TestParseSpanDecorationsAbsent: omitting the flags leaves the
+// decorations at their zero values.
+func TestParseSpanDecorationsAbsent(t *testing.T) {
+	runs, _, _, _, err := parseSpanMessage("s 0 5 - bold", 100)
+	if err != nil {
+		t.Fatalf("parseSpanMessage: %v", err)
+	}
+	st := runs[0].Style
+	if st.Underline || st.Strike || st.Squiggle != nil {
+		t.Errorf("decorations should be unset, got underline=%v strike=%v squiggle=%v",
+			st.Underline, st.Strike, st.Squiggle)
+	}
+}
+
+func TestParseSpanSquiggleErrors(t *testing.T) {
+	cases := []string{
+		"s 0 5 - squiggle=",
+		"s 0 5 - squiggle=-",
+		"s 0 5 - squiggle=red",
+		"s 0 5 - squiggle=#ff00",
+	}
+	for _, data := range cases {
+		if _, _, _, _, err := parseSpanMessage(data, 100); err == nil {
+			t.Errorf("parseSpanMessage(%q) should fail", data)
+		}
+	}
+}
+
+// TestStyleAttrsEqualDecorations: runs that differ only in their
+// decorations are distinct.
+func TestStyleAttrsEqualDecorations(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	tests := []struct {
+		a, b StyleAttrs
+		want bool
+	}{
+		{StyleAttrs{Underline: true}, StyleAttrs{Underline: true}, true},
+		{StyleAttrs{Underline: true}, StyleAttrs{}, false},
+		{StyleAttrs{Strike: true}, StyleAttrs{}, false},
+		{StyleAttrs{Squiggle: red}, StyleAttrs{Squiggle: red}, true},
+		{StyleAttrs{Squiggle: red}, StyleAttrs{Squiggle: blue}, false},
+		{StyleAttrs{Squiggle: red}, StyleAttrs{}, false},
+	}
+	for i, tc := range tests {
+		if got := tc.a.Equal(tc.b); got != tc.want {
+			t.Errorf("case %d: Equal = %v, want %v", i, got, tc.want)
+		}
+	}
+}
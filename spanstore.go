@@ -41,6 +41,16 @@ type StyleAttrs struct {
 	// behavior was reverted in the round-3 follow-up).
 	HRule bool
 
+	// Underline and Strike draw a line under or through the run
+	// in its foreground color. Squiggle, when non-nil, draws a
+	// wavy underline in the given color — used by linters and
+	// spell checkers to mark a range without taking over its
+	// foreground color. Wire format: `underline`, `strike` and
+	// `squiggle=#rrggbb` flags on s directives.
+	Underline bool
+	Strike    bool
+	Squiggle  color.Color
+
 	// Box fields (zero values = not a box)
 	IsBox      bool
 	BoxWidth   int    // pixels; 0 means "renderer probes" (Phase 3 round 4)
@@ -90,6 +100,9 @@ func (a StyleAttrs) Equal(b StyleAttrs) bool {
 		a.Scale == b.Scale &&
 		a.Family == b.Family &&
 		a.HRule == b.HRule &&
+		a.Underline == b.Underline &&
+		a.Strike == b.Strike &&
+		colorEqual(a.Squiggle, b.Squiggle) &&
 		a.IsBox == b.IsBox &&
 		a.BoxWidth == b.BoxWidth &&
 		a.BoxHeight == b.BoxHeight &&
@@ -197,7 +197,7 @@ func (w *Window) setTag1() {
 		}
 	}
 	// TODO(rjk): What happens if I make a directory into a file.
-	if w.body.file.IsDir() {
+	if w.body.file.IsDir() || w.body.file.ChangedOnDisk() {
 		sb.WriteString(Lget)
 	}
 	oldbarIndex := w.tag.file.IndexRune('|')
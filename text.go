@@ -83,6 +83,15 @@ type Text struct {
 	iq1 int
 	eq0 int // When 0, typing has started
 
+	// sels are the secondary selections of a body with several
+	// selections, sorted and disjoint from each other and from q0, q1.
+	// They follow edits the same way q0 and q1 do. drawnsels are the
+	// frame positions at which they were last painted. keep collects
+	// the ranges of an Edit command's k commands.
+	sels      []Range
+	drawnsels []Range
+	keep      []Range
+
 	nofill bool // When true, updates to the Text shouldn't update the frame.
 
 	// suppressEventLog gates logInsert / logInsertDelete from
@@ -389,6 +398,22 @@ func (t *Text) Load(q0 int, filename string, setqid bool) (nread int, err error)
 	return t.loadReader(q0, filename, fd, setqid && q0 == 0)
 }
 
+// loadMore adds to the body what has been added to the end of its file
+// since it was loaded, and reports whether it could. If not, the file
+// must be loaded again.
+func (t *Text) loadMore(filename string) bool {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer fd.Close()
+	_, ok, err := t.file.LoadMore(fd)
+	if err != nil {
+		warning(nil, "error reading file %s: %v\n", filename, err)
+	}
+	return ok
+}
+
 func getDirNames(f *os.File) ([]string, error) {
 	entries, err := f.Readdir(0)
 	if err != nil {
@@ -478,6 +503,21 @@ func (t *Text) Inserted(oq0 file.OffsetTuple, b []byte, nr int) {
 	if q0 < t.q0 {
 		t.q0 += nr
 	}
+	for i := range t.sels {
+		s := &t.sels[i]
+		if q0 < s.q1 {
+			s.q1 += nr
+		}
+		if q0 < s.q0 {
+			s.q0 += nr
+		}
+	}
+
+	// Diagnostics track the text they cover in every mode.
+	if t.what == Body && t.w != nil && t.w.diagStore != nil {
+		t.w.diagStore.Insert(q0, nr)
+		t.w.updateDiagMarkers()
+	}
 
 	// In preview mode, don't update the text frame directly.
 	// Record the edit for incremental update; the caller is responsible
@@ -648,6 +688,25 @@ func (t *Text) Deleted(oq0, oq1 file.OffsetTuple) {
 	if q0 < t.q1 {
 		t.q1 -= util.Min(n, t.q1-q0)
 	}
+	if len(t.sels) > 0 {
+		for i := range t.sels {
+			s := &t.sels[i]
+			if q0 < s.q0 {
+				s.q0 -= util.Min(n, s.q0-q0)
+			}
+			if q0 < s.q1 {
+				s.q1 -= util.Min(n, s.q1-q0)
+			}
+		}
+		// Selections inside the deleted text collapse onto each other.
+		t.sels = normalizeSels(Range{t.q0, t.q1}, t.sels)
+	}
+
+	// Diagnostics track the text they cover in every mode.
+	if t.what == Body && t.w != nil && t.w.diagStore != nil {
+		t.w.diagStore.Delete(q0, q1-q0)
+		t.w.updateDiagMarkers()
+	}
 
 	// In preview mode, don't update the text frame directly.
 	// Record the edit for incremental update; the caller is responsible
@@ -725,16 +784,22 @@ func (t *Text) Constrain(q0, q1 int) (p0, p1 int) {
 }
 
 func (t *Text) BsWidth(c rune) int {
+	return t.bsWidthAt(t.q0, c)
+}
+
+// bsWidthAt returns the number of runes before q0 that the erase
+// character c removes.
+func (t *Text) bsWidthAt(q0 int, c rune) int {
 	// there is known to be at least one character to erase
 	if c == 0x08 { // ^H: erase character
 		return 1
 	}
-	q := t.q0
+	q := q0
 	skipping := true
 	for q > 0 {
 		r := t.file.ReadC(q - 1)
 		if r == '\n' { // eat at most one more character
-			if q == t.q0 { // eat the newline
+			if q == q0 { // eat the newline
 				q--
 			}
 			break
@@ -751,7 +816,7 @@ func (t *Text) BsWidth(c rune) int {
 		}
 		q--
 	}
-	return t.q0 - q
+	return q0 - q
 }
 
 func (t *Text) FileWidth(q0 int, oneelement bool) int {
@@ -833,6 +898,9 @@ func (t *Text) Type(r rune) {
 	if t.what == Tag {
 		t.w.tagsafe = false
 	}
+	if len(t.sels) > 0 && t.typeSelections(r) {
+		return
+	}
 	nr = 1
 	rp := []rune{r}
 
@@ -1287,6 +1355,7 @@ func (t *Text) Select() {
 	)
 
 	selecttext = t
+	t.clearSelections()
 
 	// To have double-clicking and chording, we double-click
 	// immediately if it might make sense.
@@ -1538,19 +1607,20 @@ func (t *Text) SetSelect(q0, q1 int) {
 		panic(fmt.Sprintf("acme: textsetselect p0=%d p1=%d q0=%v q1=%v t.org=%d nchars=%d", p0, p1, q0, q1, t.org, nchars))
 	}
 
+	t.undrawSels()
 	t.fr.DrawSel(t.fr.Ptofchar(p0), p0, p1, ticked)
+	t.drawSels()
 }
 
-// TODO(rjk): The implicit initialization of q0, q1 doesn't seem like very nice
-// style? Maybe it is idiomatic?
+// Select23 sweeps a range with button 2 or 3 and returns it, along with
+// the buttons held when the sweep ended. Buttons in mask cancel the
+// sweep.
 func (t *Text) Select23(high draw.Image, mask uint) (q0, q1 int, buts uint) {
 	p0, p1 := t.fr.SelectOpt(global.mousectl, global.mouse, func(frame.SelectScrollUpdater, int) {}, t.display.White(), high)
 
 	buts = uint(global.mousectl.Mouse.Buttons)
-	if (buts & mask) == 0 {
-		q0 = p0 + t.org
-		q1 = p1 + t.org
-	}
+	q0 = p0 + t.org
+	q1 = p1 + t.org
 	for global.mousectl.Mouse.Buttons != 0 {
 		global.mousectl.Read()
 	}
@@ -1568,9 +1638,12 @@ func (t *Text) Select2() (q0, q1 int, tp *Text, ret bool) {
 	return q0, q1, nil, true
 }
 
-func (t *Text) Select3() (q0, q1 int, r bool) {
+// Select3 sweeps a range with button 3. ok reports that no other button
+// was pressed. add reports the 3-1 chord, which adds the range to the
+// selections.
+func (t *Text) Select3() (q0, q1 int, ok, add bool) {
 	q0, q1, buts := t.Select23(global.but3col, 1|2)
-	return q0, q1, buts == 0
+	return q0, q1, buts == 0, buts&1 != 0 && buts&2 == 0
 }
 
 func (t *Text) DoubleClick(inq0, inq1 int) (q0, q1 int) {
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/rjkroege/edwood/file"
+)
+
+// Undo journals.
+//
+// With -u, putting a file saves its undo history in a journal under
+// $HOME/lib/edwood/undo, named for the file's path. Opening the file in
+// a new window restores the history, so Undo goes past the point at
+// which the file was loaded, as long as the file on disk is the one the
+// journal was written with.
+
+// undoJournalDir returns the directory of the undo journals, or "" if
+// they are off.
+func undoJournalDir() string {
+	if !*undoflag || global.home == "" {
+		return ""
+	}
+	return filepath.Join(global.home, "lib", "edwood", "undo")
+}
+
+// saveUndoJournal saves the undo history of oeb, whose contents have
+// just been written to its file.
+func saveUndoJournal(oeb *file.ObservableEditableBuffer) {
+	dir := undoJournalDir()
+	if dir == "" || oeb.IsDirOrScratch() {
+		return
+	}
+	name, err := filepath.Abs(oeb.Name())
+	if err != nil {
+		return
+	}
+	if j := oeb.Journal(); j != nil {
+		j.Name = name
+		err = file.WriteJournal(dir, j)
+	} else {
+		err = file.RemoveJournal(dir, name)
+	}
+	if err != nil {
+		warning(nil, "can't save undo history of %s: %v\n", name, err)
+	}
+}
+
+// restoreUndoJournal gives the body t, just loaded from its file, the
+// undo history saved when the file was last put.
+func restoreUndoJournal(t *Text) {
+	dir := undoJournalDir()
+	oeb := t.file
+	if dir == "" || oeb.IsDirOrScratch() || oeb.HasUndoableChanges() {
+		return
+	}
+	name, err := filepath.Abs(oeb.Name())
+	if err != nil {
+		return
+	}
+	j, err := file.ReadJournal(dir, name)
+	if err != nil {
+		warning(nil, "can't read undo history of %s: %v\n", name, err)
+		return
+	}
+	if j == nil || j.Hash != oeb.Hash().String() {
+		// The file has changed since the journal was written.
+		return
+	}
+	if err := oeb.RestoreJournal(j, func() int { global.seq++; return global.seq }); err != nil {
+		warning(nil, "can't restore undo history: %v\n", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rjkroege/edwood/file"
+)
+
+func TestUndoJournal(t *testing.T) {
+	defer func(flag bool, home string) {
+		*undoflag = flag
+		global.home = home
+	}(*undoflag, global.home)
+	*undoflag = true
+	global.home = t.TempDir()
+	name := filepath.Join(global.home, "a.txt")
+
+	// loadFile reads name into a new buffer as opening a window does.
+	loadFile := func() *Text {
+		t.Helper()
+		oeb := file.MakeObservableEditableBuffer(name, nil)
+		fd, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		if _, _, err := oeb.Load(0, fd, true); err != nil {
+			t.Fatal(err)
+		}
+		oeb.Clean()
+		return &Text{file: oeb}
+	}
+	putFile := func(oeb *file.ObservableEditableBuffer) {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(oeb.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		oeb.SetHash(file.CalcHash([]byte(oeb.String())))
+		oeb.Clean()
+		saveUndoJournal(oeb)
+	}
+
+	if err := os.WriteFile(name, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oeb := loadFile().file
+	global.seq++
+	oeb.Mark(global.seq)
+	oeb.InsertAt(5, []rune(", world"))
+	putFile(oeb)
+
+	text := loadFile()
+	restoreUndoJournal(text)
+	if !text.file.HasUndoableChanges() || text.file.Dirty() {
+		t.Fatalf("restored file: undoable %v, dirty %v; want true, false", text.file.HasUndoableChanges(), text.file.Dirty())
+	}
+	text.file.Undo(true)
+	if got, want := text.file.String(), "hello\n"; got != want {
+		t.Errorf("after undo, body is %q; want %q", got, want)
+	}
+
+	// A file changed behind edwood's back keeps no history.
+	if err := os.WriteFile(name, []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	text = loadFile()
+	restoreUndoJournal(text)
+	if text.file.HasUndoableChanges() {
+		t.Errorf("history restored for a changed file")
+	}
+
+	// Putting a file without history removes its journal.
+	putFile(text.file)
+	if _, err := os.Stat(file.JournalPath(undoJournalDir(), name)); !os.IsNotExist(err) {
+		t.Errorf("journal remains after putting a file without history: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rjkroege/edwood/file"
+)
+
+// Undo history.
+//
+// A file's undo history is a tree (see package file). History lists it
+// in a window named for the file with +Undo appended, one state per
+// line:
+//
+//	Undo 0	Oct 16 09:12:01	seq 0	start
+//	Undo 1	Oct 16 09:12:07	seq 4	after 0
+//	Undo 2	Oct 16 09:12:30	seq 9	after 1
+//	Undo 3	Oct 16 09:13:02	seq 12	after 1	current
+//
+// Executing Undo with a state number, such as by sweeping "Undo 2" with
+// B2, undoes and redoes to reach that state. In the +Undo window, Undo n
+// and History act on the file's window, and the list is kept up to date.
+
+const plusUndo = "+Undo"
+
+// historyTarget returns the window whose history a command executed in
+// w acts on: w, or the window of the file that w lists the history of.
+func historyTarget(w *Window) *Window {
+	if name, ok := strings.CutSuffix(w.body.file.Name(), plusUndo); ok {
+		return lookfile(name)
+	}
+	return w
+}
+
+// history lists the undo history of the window's file in its +Undo
+// window.
+func history(et *Text, _ *Text, _ *Text, _, _ bool, _ string) {
+	if et == nil || et.w == nil {
+		return
+	}
+	w := historyTarget(et.w)
+	if w == nil {
+		warning(nil, "History: no window for %s\n", et.w.body.file.Name())
+		return
+	}
+	showHistory(w, true)
+}
+
+// showHistory writes the undo history of w's file to its +Undo window,
+// making the window if create is set.
+func showHistory(w *Window, create bool) {
+	name := w.body.file.Name() + plusUndo
+	hw := lookfile(name)
+	if hw == nil {
+		if !create {
+			return
+		}
+		hw = makenewwindow(&w.body)
+		hw.SetName(name)
+		hw.filemenu = false
+		xfidlog(hw, "new")
+	}
+	t := &hw.body
+	t.Delete(0, t.Nc(), true)
+	t.Insert(0, []rune(formatHistory(w.body.file.History())), true)
+	t.file.Clean()
+	t.Show(0, 0, true)
+	t.ScrDraw()
+}
+
+// formatHistory returns the listing of states written by History.
+func formatHistory(states []file.State) string {
+	var b strings.Builder
+	for _, st := range states {
+		fmt.Fprintf(&b, "Undo %d\t%s\tseq %d", st.ID, st.Time.Format("Jan _2 15:04:05"), st.Seq)
+		if st.ID == 0 {
+			b.WriteString("\tstart")
+		} else {
+			fmt.Fprintf(&b, "\tafter %d", st.Parent)
+		}
+		if st.Current {
+			b.WriteString("\tcurrent")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// undoto implements Undo n: it moves the file of the window, or of the
+// +Undo window, et to state n of its history.
+func undoto(et *Text, arg string) {
+	w := historyTarget(et.w)
+	if w == nil {
+		warning(nil, "Undo: no window for %s\n", et.w.body.file.Name())
+		return
+	}
+	id, err := strconv.Atoi(strings.Fields(arg)[0])
+	if err != nil || id < 0 || id >= len(w.body.file.History()) {
+		warning(nil, "Undo: bad state %q\n", arg)
+		return
+	}
+	w.UndoTo(id)
+	showHistory(w, false)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rjkroege/edwood/file"
+)
+
+func TestFormatHistory(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 12, 1, 0, time.UTC)
+	got := formatHistory([]file.State{
+		{ID: 0, Time: at},
+		{ID: 1, Parent: 0, Seq: 4, Time: at.Add(6 * time.Second)},
+		{ID: 2, Parent: 1, Seq: 9, Time: at.Add(29 * time.Second), Current: true},
+	})
+	want := "Undo 0\tOct 16 09:12:01\tseq 0\tstart\n" +
+		"Undo 1\tOct 16 09:12:07\tseq 4\tafter 0\n" +
+		"Undo 2\tOct 16 09:12:30\tseq 9\tafter 1\tcurrent\n"
+	if got != want {
+		t.Errorf("formatHistory gave\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUndoToBranch(t *testing.T) {
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin("/home/gopher/a.txt"),
+		ScBody("/home/gopher/a.txt", "base"),
+	)
+	w := global.row.col[0].w[0]
+	edit := func(s string) {
+		global.seq++
+		w.body.file.Mark(global.seq)
+		w.body.file.InsertAt(w.body.file.Nr(), []rune(s))
+	}
+
+	edit(" one")
+	edit(" two")
+	undo(&w.tag, nil, nil, true, false, "")
+	edit(" three")
+
+	undo(&w.tag, nil, nil, true, false, "2\tOct 16 09:12:30\tseq 9")
+	if got, want := w.body.file.String(), "base one two"; got != want {
+		t.Errorf("after Undo 2, body is %q; want %q", got, want)
+	}
+	undo(&w.tag, nil, nil, true, false, "0")
+	if got, want := w.body.file.String(), "base"; got != want {
+		t.Errorf("after Undo 0, body is %q; want %q", got, want)
+	}
+	// Redo follows the branch most recently left.
+	undo(&w.tag, nil, nil, false, false, "")
+	undo(&w.tag, nil, nil, false, false, "")
+	if got, want := w.body.file.String(), "base one two"; got != want {
+		t.Errorf("after redoing, body is %q; want %q", got, want)
+	}
+
+	// A bad state changes nothing.
+	warningsMu.Lock()
+	warnings = []*Warning{}
+	warningsMu.Unlock()
+	undo(&w.tag, nil, nil, true, false, "9")
+	if got, want := w.body.file.String(), "base one two"; got != want {
+		t.Errorf("after Undo 9, body is %q; want %q", got, want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].buf.String(), "bad state") {
+		t.Errorf("Undo 9 did not warn of a bad state")
+	}
+}
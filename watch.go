@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rjkroege/edwood/file"
+)
+
+// Files changed by other programs.
+//
+// watchthread checks the files of the windows every watchInterval and,
+// where the system can report changes to a directory (inotify on Linux),
+// soon after one of their directories changes. The body of a clean
+// window is brought up to date with its file, as by Get. A body with
+// unsaved changes is left as it is: a warning names the file, and its
+// tag shows Get until it is Put or Get.
+
+// watchInterval is how often watchthread checks the files of the windows.
+var watchInterval = 2 * time.Second
+
+// watchDelay is how long watchthread waits after a change is reported,
+// so that a burst of changes is checked once.
+const watchDelay = 50 * time.Millisecond
+
+// A dirWatcher reports changes in the directories it watches.
+type dirWatcher interface {
+	Watch(dirs []string)      // watch dirs, and only dirs
+	Changed() <-chan struct{} // receives after a change
+}
+
+// pollWatcher is a dirWatcher that reports nothing, for systems without
+// a way to watch directories. watchthread still polls.
+type pollWatcher struct{}
+
+func (pollWatcher) Watch([]string)           {}
+func (pollWatcher) Changed() <-chan struct{} { return nil }
+
+func watchthread(g *globals) {
+	dw := newDirWatcher()
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-dw.Changed():
+			time.Sleep(watchDelay)
+		case <-tick.C:
+		}
+		dirs := make(chan []string, 1)
+		g.previewRenderCh <- func() {
+			dirs <- checkFiles()
+		}
+		dw.Watch(<-dirs)
+	}
+}
+
+// checkFiles checks the file of each window for changes made by other
+// programs, and returns the directories of the files.
+func checkFiles() []string {
+	global.row.lk.Lock()
+	defer global.row.lk.Unlock()
+
+	checked := make(map[*file.ObservableEditableBuffer]bool)
+	seen := make(map[string]bool)
+	var dirs []string
+	global.row.AllWindows(func(w *Window) {
+		f := w.body.file
+		if checked[f] || f.IsDirOrScratch() || f.Info() == nil {
+			return
+		}
+		checked[f] = true
+		if dir := filepath.Dir(f.Name()); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+		w.Lock('W')
+		if w.body.checkFile() && w.IsPreviewMode() {
+			w.UpdatePreview()
+		}
+		w.Unlock()
+	})
+	return dirs
+}
+
+// checkFile brings a clean body up to date with its file, and marks the
+// file of a body with unsaved changes as changed on disk if it has been,
+// warning once. It reports whether the body changed.
+func (t *Text) checkFile() bool {
+	f := t.file
+	if !f.Dirty() {
+		return t.reloadFile()
+	}
+	if !f.ChangedOnDisk() && t.fileChanged() {
+		f.SetChangedOnDisk(true)
+		warning(nil, "%s changed on disk; Get discards your changes, Put overwrites the file\n", f.Name())
+	}
+	return false
+}
+
+// fileChanged reports whether the file of the body is not the one, or
+// does not have the text, that it was last read from or written to. A
+// file that cannot be read, as between renaming a log and making the
+// next, has not changed.
+func (t *Text) fileChanged() bool {
+	f := t.file
+	d, err := os.Stat(f.Name())
+	if err != nil || d.IsDir() {
+		return false
+	}
+	info := f.Info()
+	if info == nil {
+		return true
+	}
+	if os.SameFile(info, d) && info.Size() == d.Size() && info.ModTime().Equal(d.ModTime()) {
+		return false
+	}
+	if info.Size() == d.Size() {
+		// Only the time may have changed, such as by touch.
+		if h, err := file.HashFor(f.Name()); err == nil && h.Eq(f.Hash()) {
+			f.SetInfo(d)
+			return false
+		}
+	}
+	return true
+}
+
+// reloadFile brings a clean body up to date with its file, if the file
+// has changed. Text added to the end of the file is added to the body; a
+// file that was truncated or replaced, as when a log is rotated, is
+// loaded again. A body whose dot is at the end scrolls to show what was
+// added. reloadFile reports whether the body changed.
+func (t *Text) reloadFile() bool {
+	f := t.file
+	if f.Dirty() || f.IsDirOrScratch() || !t.fileChanged() {
+		return false
+	}
+	name := f.Name()
+	atend := t.q0 == t.q1 && t.q1 == f.Nr()
+	global.seq++
+	f.Mark(global.seq)
+	if !t.loadMore(name) {
+		t.Delete(0, f.Nr(), true)
+		t.Load(0, name, true)
+	}
+	f.Clean()
+	if atend {
+		t.Show(f.Nr(), f.Nr(), true)
+	}
+	return true
+}
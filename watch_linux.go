@@ -0,0 +1,92 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"log"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyWatcher is a dirWatcher that uses inotify.
+type inotifyWatcher struct {
+	fd int
+	c  chan struct{}
+
+	mu  sync.Mutex
+	wds map[string]int // watch descriptors by directory
+}
+
+// inotifyMask selects the changes to a directory that may change a file
+// in it.
+const inotifyMask = unix.IN_MODIFY | unix.IN_ATTRIB | unix.IN_CLOSE_WRITE |
+	unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// newDirWatcher returns an inotifyWatcher, or a pollWatcher if inotify
+// cannot be used.
+func newDirWatcher() dirWatcher {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC)
+	if err != nil {
+		log.Printf("inotify: %v; polling for changes to files", err)
+		return pollWatcher{}
+	}
+	w := &inotifyWatcher{
+		fd:  fd,
+		c:   make(chan struct{}, 1),
+		wds: make(map[string]int),
+	}
+	go w.read()
+	return w
+}
+
+// read reports that a change was made whenever inotify has events. The
+// files changed are found by checking them, so the events are not
+// parsed.
+func (w *inotifyWatcher) read() {
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := unix.Read(w.fd, buf)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || n <= 0 {
+			log.Printf("inotify: read failed: %v; polling for changes to files", err)
+			return
+		}
+		select {
+		case w.c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *inotifyWatcher) Watch(dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	keep := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		keep[dir] = true
+		if _, ok := w.wds[dir]; ok {
+			continue
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, dir, inotifyMask)
+		if err != nil {
+			// The directory may be gone, or there may be too many
+			// watches. Polling still finds the changes.
+			continue
+		}
+		w.wds[dir] = wd
+	}
+	for dir, wd := range w.wds {
+		if !keep[dir] {
+			unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, dir)
+		}
+	}
+}
+
+func (w *inotifyWatcher) Changed() <-chan struct{} {
+	return w.c
+}
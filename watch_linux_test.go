@@ -0,0 +1,53 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInotifyWatcher(t *testing.T) {
+	w, ok := newDirWatcher().(*inotifyWatcher)
+	if !ok {
+		t.Skip("inotify is not available")
+	}
+	dir, other := t.TempDir(), t.TempDir()
+
+	changed := func() bool {
+		select {
+		case <-w.Changed():
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	w.Watch([]string{dir, other})
+	if len(w.wds) != 2 {
+		t.Fatalf("watching %v; want %s and %s", w.wds, dir, other)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "f"), []byte("text"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if !changed() {
+		t.Errorf("no change reported after writing a file")
+	}
+
+	w.Watch([]string{dir})
+	if _, ok := w.wds[other]; ok || len(w.wds) != 1 {
+		t.Errorf("watching %v; want only %s", w.wds, dir)
+	}
+	for changed() {
+		// Drain the events of the first write.
+	}
+	if err := os.WriteFile(filepath.Join(other, "f"), []byte("text"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if changed() {
+		t.Errorf("change reported in a directory no longer watched")
+	}
+}
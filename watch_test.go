@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(name, []byte("one\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	FlexiblyMakeWindowScaffold(
+		t,
+		ScWin(name),
+		ScBody(name, ""),
+	)
+	w := global.row.col[0].w[0]
+	body := &w.body
+	body.Load(0, name, true)
+	body.file.Clean()
+	warnings = nil
+
+	// Times on disk may be coarse, so each write moves them on.
+	mtime := time.Now()
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(s), 0666); err != nil {
+			t.Fatal(err)
+		}
+		mtime = mtime.Add(time.Second)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if body.checkFile() {
+		t.Errorf("checkFile changed the body of an unchanged file")
+	}
+
+	write("two\n")
+	if !body.checkFile() {
+		t.Errorf("checkFile did not reload a clean body")
+	}
+	if got, want := body.file.String(), "two\n"; got != want {
+		t.Errorf("clean body is %q; want %q", got, want)
+	}
+	if body.file.Dirty() || body.file.ChangedOnDisk() {
+		t.Errorf("reloaded body is dirty %v, changed on disk %v; want neither", body.file.Dirty(), body.file.ChangedOnDisk())
+	}
+
+	// Touching the file does not change its text.
+	mtime = mtime.Add(time.Second)
+	if err := os.Chtimes(name, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if body.fileChanged() {
+		t.Errorf("fileChanged reported a touched file")
+	}
+
+	global.seq++
+	body.file.Mark(global.seq)
+	body.file.InsertAt(0, []rune("edit\n"))
+	write("three\n")
+	if body.checkFile() {
+		t.Errorf("checkFile changed a dirty body")
+	}
+	if got, want := body.file.String(), "edit\ntwo\n"; got != want {
+		t.Errorf("dirty body is %q; want %q", got, want)
+	}
+	if !body.file.ChangedOnDisk() {
+		t.Errorf("dirty body not marked as changed on disk")
+	}
+	if tag := w.tag.file.String(); !strings.Contains(tag, " Get") {
+		t.Errorf("tag %q does not offer Get", tag)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].buf.String(), name+" changed on disk") {
+		t.Errorf("got warnings %v; want one for %s", warnings, name)
+	}
+
+	// The change is reported once.
+	write("four\n")
+	body.checkFile()
+	if len(warnings) != 1 || strings.Count(warnings[0].buf.String(), "changed on disk") != 1 {
+		t.Errorf("checkFile warned again: %q", warnings[0].buf.String())
+	}
+
+	body.file.Clean()
+	if body.file.ChangedOnDisk() {
+		t.Errorf("Clean left the body changed on disk")
+	}
+	if tag := w.tag.file.String(); strings.Contains(tag, " Get") {
+		t.Errorf("tag %q still offers Get", tag)
+	}
+}
@@ -43,6 +43,11 @@ type Window struct {
 	wrselrange Range
 	rdselfd    *os.File // temporary file for rdsel read requests
 
+	// While wrselmulti is set, text written to wrsel is collected in
+	// wrselbuf to replace the body's selections when wrsel is closed.
+	wrselmulti bool
+	wrselbuf   []rune
+
 	col    *Column
 	eventx *Xfid
 	events []byte
@@ -70,6 +75,8 @@ type Window struct {
 
 	editoutlk chan bool
 
+	follow chan struct{} // closed to stop following the file; nil if not following
+
 	// Preview mode fields for rich text rendering
 	previewMode      bool                // true when showing rendered markdown preview
 	richBody         *RichText           // rich text renderer for preview mode
@@ -97,6 +104,9 @@ type Window struct {
 	styledMode       bool         // true when showing span-styled text via rich.Frame
 	styledSuppressed bool         // true when user explicitly chose Plain; suppresses auto-enable
 
+	diagStore *DiagStore  // diagnostics written to the diagnostics file (nil when none)
+	lastDiag  *Diagnostic // diagnostic most recently shown for a B1 click
+
 	fontTables map[string]*richFontTable // cached font tables, keyed by font path
 }
 
@@ -455,6 +465,21 @@ func (w *Window) Delete() {
 }
 
 func (w *Window) Undo(isundo bool) {
+	w.undo(func(f *file.ObservableEditableBuffer) (int, int, bool) {
+		return f.Undo(isundo)
+	})
+}
+
+// UndoTo moves the body to the state with id in its undo history.
+func (w *Window) UndoTo(id int) {
+	w.undo(func(f *file.ObservableEditableBuffer) (int, int, bool) {
+		return f.UndoTo(id)
+	})
+}
+
+// undo moves the body through its undo history with move, which
+// returns the new selection and whether it is meaningful.
+func (w *Window) undo(move func(*file.ObservableEditableBuffer) (int, int, bool)) {
 	w.utflastqid = -1
 	body := &w.body
 
@@ -466,7 +491,7 @@ func (w *Window) Undo(isundo bool) {
 	// triggers FlattenHistory, permanently destroying the undo stack.
 	body.eq0 = ^0
 
-	if q0, q1, ok := body.file.Undo(isundo); ok {
+	if q0, q1, ok := move(body.file); ok {
 		body.q0, body.q1 = q0, q1
 	}
 
@@ -2591,6 +2616,61 @@ func (w *Window) clearSpansAndRegions() {
 	}
 }
 
+// updateDiagMarkers sets the body's scrollbar markers, one per
+// diagnostic, and redraws the scrollbar. In preview mode the markers
+// are placed by body offset, which only approximates their rendered
+// position.
+func (w *Window) updateDiagMarkers() {
+	var markers []ScrollMarker
+	if w.diagStore != nil {
+		n := w.body.Nc()
+		for _, d := range w.diagStore.All() {
+			col := global.diagcolors[d.Severity]
+			if col == nil {
+				continue
+			}
+			frac := 0.0
+			if n > 0 {
+				frac = float64(d.Start) / float64(n)
+			}
+			markers = append(markers, ScrollMarker{Frac: frac, Color: col})
+		}
+	}
+	if w.body.scrollbar != nil {
+		w.body.scrollbar.SetMarkers(markers)
+		w.body.ScrDraw()
+	}
+	if w.richBody != nil && w.richBody.scrollbar != nil {
+		w.richBody.scrollbar.SetMarkers(markers)
+		if w.IsStyledMode() || w.IsPreviewMode() {
+			w.richBody.scrollbar.Draw()
+		}
+	}
+}
+
+// showDiagnostics writes the messages of the diagnostics overlapping
+// [q0, q1) to the +Errors window, addressed so that B3 on a message
+// returns to its range. A B1 click (repeat false) does not repeat the
+// diagnostic it showed last; B3 (repeat true) always shows.
+func (w *Window) showDiagnostics(q0, q1 int, repeat bool) {
+	if w.diagStore == nil {
+		return
+	}
+	diags := w.diagStore.At(q0, q1)
+	if len(diags) == 0 {
+		w.lastDiag = nil
+		return
+	}
+	if !repeat && diags[0] == w.lastDiag {
+		return
+	}
+	w.lastDiag = diags[0]
+	name := w.body.file.Name()
+	for _, d := range diags {
+		warning(nil, "%s:#%d,#%d: %v: %s\n", name, d.Start, d.End, d.Severity, d.Message)
+	}
+}
+
 // exitStyledMode switches the window from styled rendering back to plain mode.
 // No-op if not in styled mode.
 func (w *Window) exitStyledMode() {
@@ -3035,6 +3115,9 @@ func applyTableCellRegion(s *rich.Style, r *Region) {
 // line across the frame on the same row. Added in Phase 3 round
 // 3; the original "suppress text" behavior was reverted in the
 // round-3 follow-up.
+//
+// Underline, Strike and Squiggle pass through directly; rich.Frame
+// paints them after the text.
 func styleAttrsToRichStyle(sa StyleAttrs) rich.Style {
 	s := rich.Style{
 		Scale: 1.0,
@@ -3050,6 +3133,9 @@ func styleAttrsToRichStyle(sa StyleAttrs) rich.Style {
 		s.Code = true
 	}
 	s.HRule = sa.HRule
+	s.Underline = sa.Underline
+	s.Strike = sa.Strike
+	s.Squiggle = sa.Squiggle
 	return s
 }
 
@@ -3282,6 +3368,9 @@ func (w *Window) HandleStyledMouse(m *draw.Mouse, mc *draw.Mousectl) bool {
 		w.body.q0 = p0
 		w.body.q1 = p1
 		q0 := p0
+		if p0 == p1 {
+			w.showDiagnostics(p0, p1, false)
+		}
 
 		// Chord processing loop.
 		const (
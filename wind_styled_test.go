@@ -2128,6 +2128,26 @@ func TestStyleAttrsToRichStyle_HRuleFalsePassedThrough(t *testing.T) {
 	}
 }
 
+// TestStyleAttrsToRichStyle_DecorationsPassedThrough: Underline,
+// Strike and Squiggle map directly onto rich.Style.
+func TestStyleAttrsToRichStyle_DecorationsPassedThrough(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	sa := StyleAttrs{Underline: true, Strike: true, Squiggle: red}
+	got := styleAttrsToRichStyle(sa)
+	if !got.Underline {
+		t.Error("rich.Style.Underline should be true")
+	}
+	if !got.Strike {
+		t.Error("rich.Style.Strike should be true")
+	}
+	if got.Squiggle != red {
+		t.Errorf("rich.Style.Squiggle = %v, want %v", got.Squiggle, red)
+	}
+	if got.Fg != nil {
+		t.Errorf("rich.Style.Fg = %v, want nil", got.Fg)
+	}
+}
+
 // TestBoxStyleToRichStyle_HRuleAlsoMapped: box path honors HRule.
 func TestBoxStyleToRichStyle_HRuleAlsoMapped(t *testing.T) {
 	sa := StyleAttrs{HRule: true, IsBox: true, BoxWidth: 100, BoxHeight: 1}
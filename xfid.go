@@ -126,7 +126,7 @@ func xfidopen(x *Xfid) {
 			os.Remove(tmp.Name()) // tempfile ORCLOSE
 			w.nopen[q]++
 
-			_, err = io.Copy(tmp, t.file.Reader(t.q0, t.q1))
+			_, err = io.Copy(tmp, t.selectionsReader())
 			if err != nil || testIOCopyFail {
 				// TODO(fhs): Do we want to send an error response to the client?
 				warning(nil, "can't write temp file for pipe command %v\n", err)
@@ -136,8 +136,13 @@ func xfidopen(x *Xfid) {
 			w.nopen[q]++
 			global.seq++
 			t.file.Mark(global.seq)
-			cut(t, t, nil, false, true, "")
-			w.wrselrange = Range{t.q1, t.q1}
+			if len(t.sels) > 0 {
+				w.wrselmulti = true
+				w.wrselbuf = nil
+			} else {
+				cut(t, t, nil, false, true, "")
+				w.wrselrange = Range{t.q1, t.q1}
+			}
 			w.nomark = true
 		case QWeditout:
 			if global.editing == Inactive {
@@ -153,7 +158,7 @@ func xfidopen(x *Xfid) {
 				return
 			}
 			w.wrselrange = Range{t.q1, t.q1}
-		case QWspans:
+		case QWspans, QWdiagnostics:
 			w.nopen[q]++
 		}
 		w.Unlock()
@@ -231,11 +236,17 @@ func xfidclose(x *Xfid) {
 		case QWwrsel:
 			w.nomark = false
 			t := &w.body
-			t.Show(util.Min(w.wrselrange.q0, t.Nc()), util.Min(w.wrselrange.q1, t.Nc()), true)
+			if w.wrselmulti {
+				w.wrselmulti = false
+				t.replaceSelections(w.wrselbuf)
+				w.wrselbuf = nil
+			} else {
+				t.Show(util.Min(w.wrselrange.q0, t.Nc()), util.Min(w.wrselrange.q1, t.Nc()), true)
+			}
 			t.ScrDraw()
 		case QWeditout:
 			<-w.editoutlk
-		case QWspans:
+		case QWspans, QWdiagnostics:
 			w.nopen[q]--
 		}
 		w.Close()
@@ -338,6 +349,13 @@ func xfidread(x *Xfid) {
 		x.respond(&fc, nil)
 	case QWspans:
 		x.respond(&fc, ErrPermission)
+	case QWdiagnostics:
+		var buf string
+		if w.diagStore != nil {
+			buf = w.diagStore.String()
+		}
+		ninep.ReadString(&fc, &x.fcall, buf)
+		x.respond(&fc, nil)
 	default:
 		x.respond(&fc, fmt.Errorf("unknown qid %d in read", q))
 	}
@@ -390,7 +408,7 @@ func xfidwrite(x *Xfid) {
 	w := x.f.w
 	if w != nil {
 		c := 'F'
-		if qid == QWtag || qid == QWbody || qid == QWspans {
+		if qid == QWtag || qid == QWbody || qid == QWspans || qid == QWdiagnostics {
 			c = 'E'
 		}
 		w.Lock(int(c))
@@ -499,7 +517,16 @@ func xfidwrite(x *Xfid) {
 		w = errorwinforwin(w)
 		updateText(&w.body)
 
-	case QWbody, QWwrsel:
+	case QWwrsel:
+		if w.wrselmulti {
+			w.wrselbuf = append(w.wrselbuf, fullrunewrite(x)...)
+			fc.Count = x.fcall.Count
+			x.respond(&fc, nil)
+			break
+		}
+		updateText(&w.body)
+
+	case QWbody:
 		updateText(&w.body)
 
 	case QWctl:
@@ -554,6 +581,9 @@ func xfidwrite(x *Xfid) {
 	case QWspans:
 		xfidspanswrite(x, w)
 
+	case QWdiagnostics:
+		xfiddiagnosticswrite(x, w)
+
 	default:
 		x.respond(&fc, fmt.Errorf("unknown qid %d in write", qid))
 	}
@@ -642,6 +672,35 @@ func xfidspanswrite(x *Xfid, w *Window) {
 	x.respond(&fc, nil)
 }
 
+// xfiddiagnosticswrite adds the diagnostics in a write to the
+// window's diagnostics file, or clears them on a "c" write. The format
+// is described at parseDiagnostics.
+func xfiddiagnosticswrite(x *Xfid, w *Window) {
+	var fc plan9.Fcall
+
+	diags, isClear, err := parseDiagnostics(string(x.fcall.Data), w.body.Nc())
+	if err != nil {
+		x.respond(&fc, err)
+		return
+	}
+	switch {
+	case isClear:
+		if w.diagStore != nil {
+			w.diagStore.Clear()
+		}
+		w.lastDiag = nil
+	case len(diags) > 0:
+		if w.diagStore == nil {
+			w.diagStore = NewDiagStore()
+		}
+		w.diagStore.Add(diags)
+	}
+	w.updateDiagMarkers()
+
+	fc.Count = x.fcall.Count
+	x.respond(&fc, nil)
+}
+
 func xfidctlwrite(x *Xfid, w *Window) {
 	// log.Println("xfidctlwrite", x)
 	// defer log.Println("done xfidctlwrite")
@@ -749,6 +808,10 @@ forloop:
 			get(&w.body, nil, nil, false, false, "")
 		case "put": // put file
 			put(&w.body, nil, nil, false, false, "")
+		case "follow": // add to body as file grows
+			w.startFollow()
+		case "nofollow": // stop following file
+			w.stopFollow()
 		case "dot=addr": // set dot
 			w.body.Commit()
 			w.ClampAddr()